@@ -37,10 +37,13 @@ import (
 	"github.com/odeke-em/cli-spinner"
 	"github.com/odeke-em/command"
 	"github.com/odeke-em/go-utils/fread"
-	"github.com/odeke-em/semalim"
 )
 
-const repeatSentinel = "n"
+const (
+	repeatSentinel = "n"
+
+	maxEstimates = 400
+)
 
 var mapboxClient *mapbox.Client
 
@@ -368,21 +371,22 @@ func (o *orderCmd) Run(args []string, defaults map[string]*flag.Flag) {
 		"Choice", "Name", "Estimate", "Currency",
 		"Pickup ETA (minutes)", "Duration (minutes)",
 	})
-	for i, est := range estimates {
-		et := est.Estimate
-		ufare := est.UpfrontFare
+	for i, quote := range estimates {
+		fare, _ := quote.Fare()
+		eta, _ := quote.PickupETA()
+		duration, _ := quote.TripDuration()
 		table.Append([]string{
 			fmt.Sprintf("%d", i),
-			fmt.Sprintf("%s", et.LocalizedName),
-			fmt.Sprintf("%s", et.Estimate),
-			fmt.Sprintf("%s", et.CurrencyCode),
-			fmt.Sprintf("%.1f", ufare.PickupEstimateMinutes),
-			fmt.Sprintf("%.1f", et.DurationSeconds/60.0),
+			fmt.Sprintf("%s", quote.LocalizedName),
+			fmt.Sprintf("%s", quoteEstimate(quote)),
+			fmt.Sprintf("%s", fare.CurrencyCode),
+			fmt.Sprintf("%.1f", eta.Minutes()),
+			fmt.Sprintf("%.1f", duration.Minutes()),
 		})
 	}
 	table.Render()
 
-	var estimateChoice *estimateAndUpfrontFarePair
+	var estimateChoice *uber.ProductQuote
 
 	for {
 		fmt.Printf("Please enter the choice of your item or n to cancel ")
@@ -406,6 +410,9 @@ func (o *orderCmd) Run(args []string, defaults map[string]*flag.Flag) {
 	if estimateChoice == nil {
 		log.Fatal("illogical error, estimateChoice cannot be nil")
 	}
+	if estimateChoice.UpfrontFare == nil || estimateChoice.UpfrontFare.Fare == nil {
+		log.Fatalf("no upfront fare for %s: %v", estimateChoice.LocalizedName, estimateChoice.UpfrontFareErr)
+	}
 
 	rreq := &uber.RideRequest{
 		StartLatitude:  startCoord.Lat,
@@ -414,7 +421,7 @@ func (o *orderCmd) Run(args []string, defaults map[string]*flag.Flag) {
 		EndLongitude:   endCoord.Lng,
 		SeatCount:      seatCount,
 		FareID:         string(estimateChoice.UpfrontFare.Fare.ID),
-		ProductID:      estimateChoice.Estimate.ProductID,
+		ProductID:      estimateChoice.ProductID,
 	}
 	spinr.Start()
 	rres, err := uberClient.RequestRide(rreq)
@@ -482,60 +489,29 @@ func main() {
 	command.ParseAndRun()
 }
 
-func doUberEstimates(uberC *uber.Client, esReq *uber.EstimateRequest, spinr *spinner.Spinner) ([]*estimateAndUpfrontFarePair, error) {
+func doUberEstimates(uberC *uber.Client, esReq *uber.EstimateRequest, spinr *spinner.Spinner) ([]*uber.ProductQuote, error) {
 	spinr.Start()
-	estimatesPageChan, cancelPaging, err := uberC.EstimatePrice(esReq)
+	esReq.MaxProducts = maxEstimates
+	quotes, err := uberC.CompareProducts(context.Background(), esReq)
 	spinr.Stop()
-	if err != nil {
+	// Make do with the estimates retrieved before any failure.
+	if err != nil && len(quotes) == 0 {
 		return nil, err
 	}
+	uber.SortByFare(quotes)
+	return quotes, nil
+}
 
-	var allEstimates []*uber.PriceEstimate
-	for page := range estimatesPageChan {
-		if page.Err == nil {
-			allEstimates = append(allEstimates, page.Estimates...)
-		}
-		if len(allEstimates) >= 400 {
-			cancelPaging()
-		}
+// quoteEstimate returns the upfront fare of quote as displayed
+// by Uber, otherwise its estimate for example "$10-13" or "Metered".
+func quoteEstimate(quote *uber.ProductQuote) string {
+	if upf := quote.UpfrontFare; upf != nil && upf.Fare != nil && upf.Fare.DisplayAmount != "" {
+		return string(upf.Fare.DisplayAmount)
 	}
-
-	spinr.Start()
-	defer spinr.Stop()
-
-	jobsBench := make(chan semalim.Job)
-	go func() {
-		defer close(jobsBench)
-
-		for i, estimate := range allEstimates {
-			jobsBench <- &lookupFare{
-				client:   uberC,
-				id:       i,
-				estimate: estimate,
-				esReq: &uber.EstimateRequest{
-					StartLatitude:  esReq.StartLatitude,
-					StartLongitude: esReq.StartLongitude,
-					StartPlace:     esReq.StartPlace,
-					EndPlace:       esReq.EndPlace,
-					EndLatitude:    esReq.EndLatitude,
-					EndLongitude:   esReq.EndLongitude,
-					SeatCount:      esReq.SeatCount,
-					ProductID:      estimate.ProductID,
-				},
-			}
-		}
-	}()
-
-	var pairs []*estimateAndUpfrontFarePair
-	resChan := semalim.Run(jobsBench, 5)
-	for res := range resChan {
-		// No ordering required so can just retrieve and add results in
-		if retr := res.Value().(*estimateAndUpfrontFarePair); retr != nil {
-			pairs = append(pairs, retr)
-		}
+	if quote.Price != nil {
+		return string(quote.Price.Estimate)
 	}
-
-	return pairs, nil
+	return ""
 }
 
 var (
@@ -619,41 +595,3 @@ func ensureUberCredsDirExists() (string, error) {
 	}
 	return curDirPath, nil
 }
-
-type lookupFare struct {
-	id       int
-	estimate *uber.PriceEstimate
-	esReq    *uber.EstimateRequest
-	client   *uber.Client
-}
-
-var _ semalim.Job = (*lookupFare)(nil)
-
-func (lf *lookupFare) Id() interface{} {
-	return lf.id
-}
-
-func (lf *lookupFare) Do() (interface{}, error) {
-	upfrontFare, err := lookupUpfrontFare(lf.client, &uber.EstimateRequest{
-		StartLatitude:  lf.esReq.StartLatitude,
-		StartLongitude: lf.esReq.StartLongitude,
-		StartPlace:     lf.esReq.StartPlace,
-		EndPlace:       lf.esReq.EndPlace,
-		EndLatitude:    lf.esReq.EndLatitude,
-		EndLongitude:   lf.esReq.EndLongitude,
-		SeatCount:      lf.esReq.SeatCount,
-		ProductID:      lf.estimate.ProductID,
-	})
-
-	return &estimateAndUpfrontFarePair{Estimate: lf.estimate, UpfrontFare: upfrontFare}, err
-}
-
-type estimateAndUpfrontFarePair struct {
-	Estimate    *uber.PriceEstimate `json:"estimate"`
-	UpfrontFare *uber.UpfrontFare   `json:"upfront_fare"`
-}
-
-func lookupUpfrontFare(c *uber.Client, rr *uber.EstimateRequest) (*uber.UpfrontFare, error) {
-	// Otherwise it is time to get the estimate of the fare
-	return c.UpfrontFare(rr)
-}
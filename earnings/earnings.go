@@ -0,0 +1,244 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package earnings summarizes the payments of a driver
+// per day, per week, per payment category and per currency.
+package earnings
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/orijtech/uber/v1"
+)
+
+// Summary totals payments made in the same currency. Deductions
+// and promotions are totaled with the sign reported by Uber, i.e
+// deductions are negative.
+type Summary struct {
+	CurrencyCode uber.CurrencyCode `json:"currency_code"`
+
+	// Payments is the number of payments totaled.
+	Payments int `json:"payments"`
+
+	// GrossFares is the total of the fares before Uber's
+	// service fees, tolls included.
+	GrossFares uber.Decimal `json:"gross_fares"`
+	Tolls      uber.Decimal `json:"tolls"`
+
+	// ServiceFees is negative like the other deductions.
+	ServiceFees uber.Decimal `json:"service_fees"`
+
+	// RiderFees is the total of the fees paid by riders
+	// that are not reflected in the fares.
+	RiderFees uber.Decimal `json:"rider_fees"`
+
+	Promotions        uber.Decimal `json:"promotions"`
+	DeviceDeductions  uber.Decimal `json:"device_deductions"`
+	VehicleDeductions uber.Decimal `json:"vehicle_deductions"`
+	Other             uber.Decimal `json:"other"`
+
+	CashCollected uber.Decimal `json:"cash_collected"`
+
+	// NetPayout is the total of the amounts paid
+	// to, or charged from, the driver's account.
+	NetPayout uber.Decimal `json:"net_payout"`
+}
+
+type CategorySummary struct {
+	Category uber.PaymentCategory `json:"category"`
+	*Summary
+}
+
+// PeriodSummary totals the payments made in [Start, End).
+type PeriodSummary struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	*Summary
+}
+
+// Report holds the summaries of payments. Since amounts in different
+// currencies cannot be added up, every grouping has one summary per
+// currency. Summaries are sorted by category or period, then by currency.
+type Report struct {
+	ByCurrency []*Summary         `json:"by_currency"`
+	ByCategory []*CategorySummary `json:"by_category"`
+
+	// ByDay and ByWeek omit the payments that have no event time.
+	ByDay  []*PeriodSummary `json:"by_day"`
+	ByWeek []*PeriodSummary `json:"by_week"`
+}
+
+type Options struct {
+	// Location is the time zone in which days and
+	// weeks are delimited. If nil, UTC is used.
+	Location *time.Location `json:"-"`
+
+	// WeekStart is the first day of weeks,
+	// which defaults to Sunday.
+	WeekStart time.Weekday `json:"week_start"`
+}
+
+func (opts *Options) location() *time.Location {
+	if opts == nil || opts.Location == nil {
+		return time.UTC
+	}
+	return opts.Location
+}
+
+func (opts *Options) weekStart() time.Weekday {
+	if opts == nil {
+		return time.Sunday
+	}
+	return opts.WeekStart
+}
+
+var errNilClient = errors.New("expecting a non-nil client")
+
+// Compute retrieves the payments of the driver in the time range of
+// dpq and summarizes them. If retrieving payments fails midway, the
+// report of the payments retrieved so far is returned with the error.
+func Compute(ctx context.Context, client *uber.Client, dpq *uber.DriverInfoQuery, opts *Options) (*Report, error) {
+	if client == nil {
+		return nil, errNilClient
+	}
+	payments, err := client.AllDriverPayments(ctx, dpq, nil)
+	return Summarize(payments, opts), err
+}
+
+type groupKey struct {
+	label    string
+	currency uber.CurrencyCode
+}
+
+type grouping map[groupKey]*Summary
+
+func (g grouping) add(label string, p *uber.Payment) {
+	key := groupKey{label: label, currency: p.AmountMoney().CurrencyCode}
+	summary, ok := g[key]
+	if !ok {
+		summary = &Summary{CurrencyCode: key.currency}
+		g[key] = summary
+	}
+	summary.add(p)
+}
+
+// sortedKeys returns the keys of g sorted by label then by currency.
+func (g grouping) sortedKeys() []groupKey {
+	keys := make([]groupKey, 0, len(g))
+	for key := range g {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].label != keys[j].label {
+			return keys[i].label < keys[j].label
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	return keys
+}
+
+func (s *Summary) add(p *uber.Payment) {
+	s.Payments += 1
+
+	amount := p.AmountMoney().Amount
+	s.NetPayout = s.NetPayout.Add(amount)
+	s.CashCollected = s.CashCollected.Add(p.CashCollectedMoney().Amount)
+	if fees := p.RiderFees; fees != nil {
+		s.RiderFees = s.RiderFees.Add(uber.DecimalFromFloat(float64(fees.Fee))).Add(uber.DecimalFromFloat(float64(fees.SplitFare)))
+	}
+
+	switch p.Category {
+	case uber.CategoryFare:
+		if bd := p.Breakdown; bd != nil {
+			toll := uber.DecimalFromFloat(float64(bd.Toll))
+			serviceFee := uber.DecimalFromFloat(float64(bd.ServiceFee))
+			s.Tolls = s.Tolls.Add(toll)
+			s.ServiceFees = s.ServiceFees.Add(serviceFee)
+			// The amount is already net of the negative service
+			// fee hence the gross is the amount without it.
+			s.GrossFares = s.GrossFares.Add(uber.DecimalFromFloat(float64(bd.Remainder))).Add(toll)
+		} else {
+			s.GrossFares = s.GrossFares.Add(amount)
+		}
+	case uber.CategoryPromotion:
+		s.Promotions = s.Promotions.Add(amount)
+	case uber.CategoryDevicePayment:
+		s.DeviceDeductions = s.DeviceDeductions.Add(amount)
+	case uber.CategoryVehiclePayment:
+		s.VehicleDeductions = s.VehicleDeductions.Add(amount)
+	default:
+		s.Other = s.Other.Add(amount)
+	}
+}
+
+// Summarize summarizes payments, skipping nil ones.
+func Summarize(payments []*uber.Payment, opts *Options) *Report {
+	loc := opts.location()
+	weekStart := opts.weekStart()
+
+	byCurrency := make(grouping)
+	byCategory := make(grouping)
+	byDay := make(grouping)
+	byWeek := make(grouping)
+	for _, p := range payments {
+		if p == nil {
+			continue
+		}
+		byCurrency.add("", p)
+		byCategory.add(string(p.Category), p)
+		if p.EventTime <= 0 {
+			continue
+		}
+		day := startOfDay(time.Unix(int64(p.EventTime), 0).In(loc))
+		byDay.add(day.Format(time.RFC3339), p)
+		byWeek.add(startOfWeek(day, weekStart).Format(time.RFC3339), p)
+	}
+
+	report := new(Report)
+	for _, key := range byCurrency.sortedKeys() {
+		report.ByCurrency = append(report.ByCurrency, byCurrency[key])
+	}
+	for _, key := range byCategory.sortedKeys() {
+		report.ByCategory = append(report.ByCategory, &CategorySummary{
+			Category: uber.PaymentCategory(key.label),
+			Summary:  byCategory[key],
+		})
+	}
+	report.ByDay = periodSummaries(byDay, loc, func(start time.Time) time.Time { return start.AddDate(0, 0, 1) })
+	report.ByWeek = periodSummaries(byWeek, loc, func(start time.Time) time.Time { return start.AddDate(0, 0, 7) })
+	return report
+}
+
+func periodSummaries(g grouping, loc *time.Location, end func(time.Time) time.Time) []*PeriodSummary {
+	var summaries []*PeriodSummary
+	// RFC 3339 labels of the same time zone sort chronologically.
+	for _, key := range g.sortedKeys() {
+		start, _ := time.ParseInLocation(time.RFC3339, key.label, loc)
+		summaries = append(summaries, &PeriodSummary{Start: start, End: end(start), Summary: g[key]})
+	}
+	return summaries
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(day time.Time, weekStart time.Weekday) time.Time {
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
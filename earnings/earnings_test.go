@@ -0,0 +1,166 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package earnings_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orijtech/uber/earnings"
+	"github.com/orijtech/uber/v1"
+)
+
+var payments = []*uber.Payment{
+	// Tuesday 2017-08-15.
+	{
+		Category:     uber.CategoryFare,
+		Amount:       0.8,
+		CurrencyCode: "USD",
+		EventTime:    1502790000,
+		Breakdown:    &uber.FareBreakdown{Remainder: 0.9, Toll: 0.1, ServiceFee: -0.2},
+		RiderFees:    &uber.ServiceFee{SplitFare: 0.05},
+	},
+	{
+		Category:      uber.CategoryFare,
+		Amount:        0.45,
+		CashCollected: 0.1,
+		CurrencyCode:  "USD",
+		EventTime:     1502800000,
+		Breakdown:     &uber.FareBreakdown{Remainder: 0.35, Toll: 0.2, ServiceFee: -0.1},
+	},
+	// Monday 2017-08-21.
+	{Category: uber.CategoryDevicePayment, Amount: -10.05, CurrencyCode: "USD", EventTime: 1503300000},
+	{Category: uber.CategoryPromotion, Amount: 5.5, CurrencyCode: "usd", EventTime: 1503300100},
+	{Category: uber.CategoryFare, Amount: 12.34, CurrencyCode: "CAD", EventTime: 1503300200},
+	// Without an event time.
+	{Category: uber.CategoryVehiclePayment, Amount: -100, CurrencyCode: "CAD"},
+	nil,
+}
+
+func TestSummarize(t *testing.T) {
+	report := earnings.Summarize(payments, nil)
+
+	wantByCurrency := map[uber.CurrencyCode]map[string]string{
+		"CAD": {
+			"net":     "-87.66",
+			"gross":   "12.34",
+			"vehicle": "-100.00",
+		},
+		"USD": {
+			"net":        "-3.30",
+			"gross":      "1.55",
+			"tolls":      "0.30",
+			"fees":       "-0.30",
+			"riderFees":  "0.05",
+			"promotions": "5.50",
+			"device":     "-10.05",
+			"cash":       "0.10",
+		},
+	}
+	if g, w := len(report.ByCurrency), len(wantByCurrency); g != w {
+		t.Fatalf("currencies: got=%d want=%d", g, w)
+	}
+	for _, summary := range report.ByCurrency {
+		want := wantByCurrency[summary.CurrencyCode]
+		got := map[string]string{
+			"net":        summary.NetPayout.StringFixed(2),
+			"gross":      summary.GrossFares.StringFixed(2),
+			"tolls":      summary.Tolls.StringFixed(2),
+			"fees":       summary.ServiceFees.StringFixed(2),
+			"riderFees":  summary.RiderFees.StringFixed(2),
+			"promotions": summary.Promotions.StringFixed(2),
+			"device":     summary.DeviceDeductions.StringFixed(2),
+			"vehicle":    summary.VehicleDeductions.StringFixed(2),
+			"cash":       summary.CashCollected.StringFixed(2),
+		}
+		for field, g := range got {
+			w, ok := want[field]
+			if !ok {
+				w = "0.00"
+			}
+			if g != w {
+				t.Errorf("%s: %s: got=%s want=%s", summary.CurrencyCode, field, g, w)
+			}
+		}
+	}
+
+	if g, w := len(report.ByCategory), 5; g != w {
+		t.Errorf("category summaries: got=%d want=%d", g, w)
+	}
+	for _, cs := range report.ByCategory {
+		if cs.Category != uber.CategoryFare || cs.CurrencyCode != "USD" {
+			continue
+		}
+		if cs.Payments != 2 {
+			t.Errorf("USD fares: got payments=%d want=2", cs.Payments)
+		}
+		// The payouts of fares are net of Uber's service fees.
+		if g, w := cs.NetPayout.StringFixed(2), "1.25"; g != w {
+			t.Errorf("USD fares: net: got=%s want=%s", g, w)
+		}
+		if g, w := cs.GrossFares.StringFixed(2), "1.55"; g != w {
+			t.Errorf("USD fares: gross: got=%s want=%s", g, w)
+		}
+		if g, w := cs.GrossFares.Add(cs.ServiceFees).StringFixed(2), cs.NetPayout.StringFixed(2); g != w {
+			t.Errorf("USD fares: gross+fees: got=%s want the net %s", g, w)
+		}
+	}
+
+	wantDays := []struct {
+		start    string
+		currency uber.CurrencyCode
+		net      string
+	}{
+		{"2017-08-15", "USD", "1.25"},
+		{"2017-08-21", "CAD", "12.34"},
+		{"2017-08-21", "USD", "-4.55"},
+	}
+	if g, w := len(report.ByDay), len(wantDays); g != w {
+		t.Fatalf("day summaries: got=%d want=%d", g, w)
+	}
+	for i, want := range wantDays {
+		got := report.ByDay[i]
+		if g := got.Start.Format("2006-01-02"); g != want.start || got.CurrencyCode != want.currency {
+			t.Errorf("day #%d: got=%s %s want=%s %s", i, g, got.CurrencyCode, want.start, want.currency)
+		}
+		if g := got.NetPayout.StringFixed(2); g != want.net {
+			t.Errorf("day #%d: net: got=%s want=%s", i, g, want.net)
+		}
+		if g := got.End.Sub(got.Start); g != 24*time.Hour {
+			t.Errorf("day #%d: got a %v long period", i, g)
+		}
+	}
+
+	// Both days are in the week that starts on Tuesday 2017-08-15,
+	// but not if weeks start on Sundays since 2017-08-20 is one.
+	tests := [...]struct {
+		weekStart time.Weekday
+		wantWeeks int
+	}{
+		0: {weekStart: time.Tuesday, wantWeeks: 2},
+		1: {weekStart: time.Sunday, wantWeeks: 3},
+	}
+	for i, tt := range tests {
+		report := earnings.Summarize(payments, &earnings.Options{WeekStart: tt.weekStart})
+		if g, w := len(report.ByWeek), tt.wantWeeks; g != w {
+			t.Errorf("#%d: week summaries: got=%d want=%d", i, g, w)
+		}
+		for _, ws := range report.ByWeek {
+			if g := ws.Start.Weekday(); g != tt.weekStart {
+				t.Errorf("#%d: week starting on a %v", i, g)
+			}
+		}
+	}
+}
@@ -15,6 +15,8 @@
 package uber_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -367,6 +369,104 @@ func Example_client_RequestRide() {
 	fmt.Printf("Your ride information: %+v\n", ride)
 }
 
+func Example_client_RequestRideContext() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Give up on the ride request if it takes longer than 10 seconds.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ride, err := client.RequestRideContext(ctx, &uber.RideRequest{
+		StartPlace: uber.PlaceHome,
+		EndPlace:   uber.PlaceWork,
+		PromptOnFare: func(fare *uber.UpfrontFare) error {
+			if fare.Fare.Value >= 15.00 {
+				return fmt.Errorf("too expensive for the daily commute")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		log.Fatalf("ride request err: %v", err)
+	}
+
+	fmt.Printf("Your ride information: %+v\n", ride)
+}
+
+func Example_client_TrackRide() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ride, err := client.RequestRide(&uber.RideRequest{
+		StartPlace:   uber.PlaceHome,
+		EndPlace:     uber.PlaceWork,
+		PromptOnFare: func(fare *uber.UpfrontFare) error { return nil },
+	})
+	if err != nil {
+		log.Fatalf("ride request err: %v", err)
+	}
+
+	events, err := client.TrackRide(context.Background(), ride.RequestID, &uber.TrackOptions{
+		PollInterval: 10 * time.Second,
+		StatusPollIntervals: map[uber.Status]time.Duration{
+			uber.StatusArriving: 3 * time.Second,
+		},
+	})
+	if err != nil {
+		log.Fatalf("track ride err: %v", err)
+	}
+
+	for ev := range events {
+		switch ev.Kind {
+		case uber.RideEventError:
+			log.Printf("poll err: %v", ev.Err)
+		case uber.RideEventStatus:
+			fmt.Printf("Status: %q => %q\n", ev.PreviousStatus, ev.Trip.Status)
+		case uber.RideEventDriver:
+			if ev.Trip.Driver != nil {
+				fmt.Printf("Your driver is: %s\n", ev.Trip.Driver.Name)
+			}
+		}
+	}
+}
+
+func Example_client_TrackDelivery() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	events, err := client.TrackDelivery(context.Background(), "71a969ca-5359-4334-a7b7-5a1705869c51", &uber.TrackOptions{
+		PollInterval: 15 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("track delivery err: %v", err)
+	}
+
+	for ev := range events {
+		switch ev.Kind {
+		case uber.DeliveryEventError:
+			log.Printf("poll err: %v", ev.Err)
+		case uber.DeliveryEventStatus:
+			fmt.Printf("Status: %q => %q\n", ev.PreviousStatus, ev.Delivery.Status)
+		case uber.DeliveryEventLocation:
+			if courier := ev.Delivery.Courier; courier != nil && courier.Location != nil {
+				loc := courier.Location
+				fmt.Printf("Courier is at: (%.4f, %.4f)\n", loc.Latitude, loc.Longitude)
+			}
+		case uber.DeliveryEventETA:
+			if dropoff := ev.Delivery.Dropoff; dropoff != nil {
+				fmt.Printf("Dropoff in %d minutes\n", dropoff.ETAMinutes)
+			}
+		}
+	}
+}
+
 func Example_client_RequestDelivery() {
 	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
 	if err != nil {
@@ -422,6 +522,74 @@ func Example_client_RequestDelivery() {
 	log.Printf("The confirmation: %+v\n", deliveryConfirmation)
 }
 
+func Example_client_RequestDeliveryQuote() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	quotes, err := client.RequestDeliveryQuote(&uber.DeliveryRequest{
+		Pickup: &uber.Endpoint{
+			Location: &uber.Location{
+				PrimaryAddress: "Empire State Building",
+				State:          "NY",
+				Country:        "US",
+			},
+		},
+		Dropoff: &uber.Endpoint{
+			Location: &uber.Location{
+				PrimaryAddress: "530 W 113th Street",
+				Country:        "US",
+				PostalCode:     "10025",
+				State:          "NY",
+			},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i, quote := range quotes {
+		fmt.Printf("#%d: QuoteID: %q Fee: %s Dropoff in: %.0f minutes\n", i, quote.ID, quote.FeeMoney(), quote.DropoffETAMinutes)
+	}
+}
+
+func Example_client_RequestDeliveries() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pickup := &uber.Endpoint{
+		Contact:  &uber.Contact{CompanyName: "orijtech", Email: "deliveries@orijtech.com"},
+		Location: &uber.Location{PrimaryAddress: "Empire State Building", State: "NY", Country: "US"},
+	}
+	var reqs []*uber.DeliveryRequest
+	for _, address := range []string{"530 W 113th Street", "636 W 28th Street"} {
+		reqs = append(reqs, &uber.DeliveryRequest{
+			Pickup: pickup,
+			Dropoff: &uber.Endpoint{
+				Contact:  &uber.Contact{FirstName: "delivery", LastName: "bot"},
+				Location: &uber.Location{PrimaryAddress: address, State: "NY", Country: "US"},
+			},
+			Items: []*uber.Item{{Title: "phone chargers", Quantity: 2}},
+		})
+	}
+
+	db, err := client.RequestDeliveries(context.Background(), reqs)
+	if err != nil {
+		log.Fatalf("invalid delivery requests: %v", err)
+	}
+	if err := db.Err(); err != nil {
+		log.Printf("some deliveries failed: %v", err)
+	}
+	fmt.Printf("Batch %q has %d deliveries\n", db.Batch.ID, db.Batch.Count)
+
+	// And if the order is called off.
+	if err := client.CancelBatch(context.Background(), db.Batch); err != nil {
+		log.Printf("failed to cancel some deliveries: %v", err)
+	}
+}
+
 func Example_client_CancelDelivery() {
 	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
 	if err != nil {
@@ -476,7 +644,7 @@ func Example_client_ListDeliveries() {
 	}
 
 	delivRes, err := client.ListDeliveries(&uber.DeliveryListRequest{
-		Status:      uber.StatusCompleted,
+		Status:      uber.DeliveryStatusCompleted,
 		StartOffset: 20,
 	})
 	if err != nil {
@@ -565,6 +733,81 @@ func Example_client_ListDriverTrips() {
 	}
 }
 
+func Example_client_DriverTripsPaginator() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	aWeekAgo := time.Now().Add(-1 * time.Hour * 7 * 24)
+	paginator, err := client.DriverTripsPaginator(&uber.DriverInfoQuery{
+		StartDate:     &aWeekAgo,
+		MaxPageNumber: 5,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for trip, err := range paginator.All(context.Background()) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("TripID: %q Fare: %.2f\n", trip.TripID, trip.Fare)
+	}
+}
+
+func Example_client_CompareProducts() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	quotes, err := client.CompareProducts(context.Background(), &uber.EstimateRequest{
+		StartLatitude:  37.7752315,
+		EndLatitude:    37.7752415,
+		StartLongitude: -122.418075,
+		EndLongitude:   -122.518075,
+		MaxProducts:    50,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	uber.SortByArrival(quotes)
+	for i, quote := range quotes {
+		fare, _ := quote.Fare()
+		arrival, _ := quote.ArrivalIn()
+		fmt.Printf("#%d: %s Fare: %s Arrival in: %v Surge: %.1f\n", i, quote.Name, fare, arrival, quote.SurgeMultiplier())
+	}
+}
+
+func Example_client_SetRidePolicy() {
+	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The policy can be in either JSON or YAML.
+	policy, err := uber.LoadRidePolicy("ride-policy.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client.SetRidePolicy(policy)
+
+	ride, err := client.RequestRide(&uber.RideRequest{
+		StartLatitude:  37.7752315,
+		EndLatitude:    37.7752415,
+		StartLongitude: -122.418075,
+		EndLongitude:   -122.518075,
+		ExpenseCode:    "ENG",
+	})
+	if pe := new(uber.PolicyError); errors.As(err, &pe) {
+		for _, v := range pe.Violations {
+			fmt.Printf("Violated %s: %s\n", v.Rule, v.Reason)
+		}
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Ride: %#v\n", ride)
+}
+
 func Example_client_TripByID() {
 	client, err := uber.NewClientFromOAuth2File(os.ExpandEnv("$HOME/.uber/credentials.json"))
 	if err != nil {
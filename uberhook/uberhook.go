@@ -17,9 +17,11 @@ package uberhook
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/orijtech/authmid"
@@ -123,3 +125,103 @@ func FparseEvent(r io.Reader) (*Event, error) {
 	}
 	return ev, nil
 }
+
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// DefaultMaxFinal is the number of resources in a final status
+// that a StatusGuard remembers if its MaxFinal is not set.
+const DefaultMaxFinal = 10000
+
+// StatusGuard keeps track of the last status of every resource
+// that it received an event for, in order to reject events that
+// arrive out of order or that describe impossible transitions.
+// Its zero value is ready to use.
+type StatusGuard struct {
+	// MaxFinal if set is the maximum number of resources in a
+	// final status to remember, the earliest to reach it being
+	// forgotten first. DefaultMaxFinal is used otherwise.
+	MaxFinal int
+
+	mu   sync.Mutex
+	last map[string]uber.Status
+
+	// final are the resources in a final status,
+	// in the order in which they reached it.
+	final []string
+}
+
+// Admit reports, with an error wrapping ErrIllegalTransition, whether
+// the resource of ev could not have gone from its last known status to
+// that of ev. Events for deliveries, whose types start with "deliveries.",
+// are checked against the delivery statuses. Statuses that are unknown
+// or events without a status are always admitted. Duplicate events are
+// admitted too. Events that would take a resource out of a final status,
+// such as late or replayed ones, are rejected.
+func (sg *StatusGuard) Admit(ev *Event) error {
+	if ev == nil || ev.Meta == nil || ev.Meta.Status == "" {
+		return nil
+	}
+
+	key := ev.Meta.ResourceID
+	next := ev.Meta.Status
+	isDelivery := strings.HasPrefix(ev.Type, "deliveries.")
+
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+
+	prev, seen := sg.last[key]
+	if seen && prev == next {
+		return nil
+	}
+	if seen && (isFinal(isDelivery, prev) || !canTransition(isDelivery, prev, next)) {
+		return fmt.Errorf("%w: %q => %q for %q", ErrIllegalTransition, prev, next, key)
+	}
+
+	if sg.last == nil {
+		sg.last = make(map[string]uber.Status)
+	}
+	sg.last[key] = next
+	if isFinal(isDelivery, next) {
+		sg.rememberFinal(key)
+	}
+	return nil
+}
+
+// rememberFinal records that the resource reached a final
+// status, forgetting the earliest ones beyond MaxFinal.
+func (sg *StatusGuard) rememberFinal(key string) {
+	maxFinal := sg.MaxFinal
+	if maxFinal <= 0 {
+		maxFinal = DefaultMaxFinal
+	}
+	sg.final = append(sg.final, key)
+	for len(sg.final) > maxFinal {
+		delete(sg.last, sg.final[0])
+		sg.final = sg.final[1:]
+	}
+}
+
+func canTransition(isDelivery bool, prev, next uber.Status) bool {
+	if isDelivery {
+		dprev, dnext := uber.DeliveryStatus(prev), uber.DeliveryStatus(next)
+		if !dprev.IsKnown() || !dnext.IsKnown() {
+			return true
+		}
+		return dprev.CanTransitionTo(dnext)
+	}
+
+	if !prev.IsKnown() || !next.IsKnown() {
+		return true
+	}
+	return prev.CanTransitionTo(next)
+}
+
+// isFinal reports whether no more events are expected
+// for a resource once it is in the given status.
+func isFinal(isDelivery bool, status uber.Status) bool {
+	if isDelivery {
+		return uber.DeliveryStatus(status).IsTerminal()
+	}
+	// A completed ride still expects the receipt to be ready.
+	return status.IsTerminal() && status != uber.StatusCompleted
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uberhook_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orijtech/uber/uberhook"
+	"github.com/orijtech/uber/v1"
+)
+
+func TestStatusGuardAdmit(t *testing.T) {
+	rideEvent := func(id string, status uber.Status) *uberhook.Event {
+		return &uberhook.Event{Type: "requests.status_changed", Meta: &uberhook.Meta{ResourceID: id, Status: status}}
+	}
+	deliveryEvent := func(id string, status uber.DeliveryStatus) *uberhook.Event {
+		return &uberhook.Event{Type: "deliveries.status_changed", Meta: &uberhook.Meta{ResourceID: id, Status: uber.Status(status)}}
+	}
+
+	tests := [...]struct {
+		maxFinal int
+		events   []*uberhook.Event
+
+		// wantRejected are the indices of the
+		// events that must be rejected.
+		wantRejected []int
+	}{
+		0: {
+			events: []*uberhook.Event{
+				rideEvent("r1", uber.StatusProcessing),
+				rideEvent("r1", uber.StatusAccepted),
+				rideEvent("r1", uber.StatusArriving),
+				// A driver re-assignment.
+				rideEvent("r1", uber.StatusAccepted),
+				rideEvent("r1", uber.StatusInProgress),
+				rideEvent("r1", uber.StatusCompleted),
+				rideEvent("r1", uber.StatusReceiptReady),
+			},
+		},
+		1: {
+			// Skipped statuses and duplicates are admitted.
+			events: []*uberhook.Event{
+				rideEvent("r1", uber.StatusProcessing),
+				rideEvent("r1", uber.StatusProcessing),
+				rideEvent("r1", uber.StatusInProgress),
+			},
+		},
+		2: {
+			events: []*uberhook.Event{
+				rideEvent("r1", uber.StatusInProgress),
+				rideEvent("r1", uber.StatusArriving),
+				rideEvent("r1", uber.StatusRiderCanceled),
+				rideEvent("r1", uber.StatusCompleted),
+			},
+			wantRejected: []int{1, 2},
+		},
+		3: {
+			// Resources are tracked independently.
+			events: []*uberhook.Event{
+				rideEvent("r1", uber.StatusInProgress),
+				rideEvent("r2", uber.StatusProcessing),
+				rideEvent("r1", uber.StatusProcessing),
+			},
+			wantRejected: []int{2},
+		},
+		4: {
+			events: []*uberhook.Event{
+				deliveryEvent("d1", uber.DeliveryStatusProcessing),
+				deliveryEvent("d1", uber.DeliveryStatusAtPickup),
+				deliveryEvent("d1", uber.DeliveryStatusClientCanceled),
+			},
+		},
+		5: {
+			events: []*uberhook.Event{
+				deliveryEvent("d1", uber.DeliveryStatusEnRouteToDropoff),
+				deliveryEvent("d1", uber.DeliveryStatusClientCanceled),
+				deliveryEvent("d1", uber.DeliveryStatusEnRouteToPickup),
+			},
+			wantRejected: []int{1, 2},
+		},
+		6: {
+			// Stale events replayed after a final status are rejected.
+			events: []*uberhook.Event{
+				rideEvent("r1", uber.StatusAccepted),
+				rideEvent("r1", uber.StatusDriverCanceled),
+				rideEvent("r1", uber.StatusArriving),
+				rideEvent("r1", uber.StatusDriverCanceled),
+				deliveryEvent("d1", uber.DeliveryStatusEnRouteToDropoff),
+				deliveryEvent("d1", uber.DeliveryStatusCompleted),
+				deliveryEvent("d1", uber.DeliveryStatusEnRouteToDropoff),
+				deliveryEvent("d1", uber.DeliveryStatusProcessing),
+			},
+			wantRejected: []int{2, 6, 7},
+		},
+		7: {
+			// Unknown statuses and events without a status are admitted.
+			events: []*uberhook.Event{
+				nil,
+				{Type: "requests.receipt_ready"},
+				rideEvent("r1", uber.StatusInProgress),
+				rideEvent("r1", "teleporting"),
+				rideEvent("r1", uber.StatusProcessing),
+			},
+		},
+		8: {
+			// Beyond MaxFinal, the earliest final resources are forgotten.
+			maxFinal: 1,
+			events: []*uberhook.Event{
+				rideEvent("r1", uber.StatusRiderCanceled),
+				rideEvent("r2", uber.StatusRiderCanceled),
+				rideEvent("r2", uber.StatusProcessing),
+				rideEvent("r1", uber.StatusProcessing),
+			},
+			wantRejected: []int{2},
+		},
+	}
+
+	for i, tt := range tests {
+		sg := &uberhook.StatusGuard{MaxFinal: tt.maxFinal}
+		var rejected []int
+		for j, ev := range tt.events {
+			err := sg.Admit(ev)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, uberhook.ErrIllegalTransition) {
+				t.Errorf("#%d: event #%d: got err=%v, want one matching %v", i, j, err, uberhook.ErrIllegalTransition)
+			}
+			rejected = append(rejected, j)
+		}
+		if len(rejected) != len(tt.wantRejected) {
+			t.Errorf("#%d: rejected: got=%v want=%v", i, rejected, tt.wantRejected)
+			continue
+		}
+		for k := range rejected {
+			if rejected[k] != tt.wantRejected[k] {
+				t.Errorf("#%d: rejected: got=%v want=%v", i, rejected, tt.wantRejected)
+				break
+			}
+		}
+	}
+}
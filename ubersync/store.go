@@ -0,0 +1,374 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ubersync
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/orijtech/uber/v1"
+)
+
+// Store persists the synced trips and the checkpoint of every source.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// PutTrips stores the trips of source that are not stored
+	// yet, as identified by tripKey, and returns how many were.
+	PutTrips(source Source, trips []*uber.Trip) (added int, err error)
+
+	// Trips returns the stored trips of source.
+	Trips(source Source) ([]*uber.Trip, error)
+
+	// Checkpoint returns the checkpoint of source,
+	// or nil if source was never synced.
+	Checkpoint(source Source) (*Checkpoint, error)
+
+	SetCheckpoint(cp *Checkpoint) error
+}
+
+var errNilCheckpoint = errors.New("expecting a non-nil checkpoint")
+
+// tripKey returns the key by which stored trips are deduplicated.
+// Trips that have neither a TripID nor a RequestID cannot be told
+// apart hence they are always stored.
+func tripKey(tr *uber.Trip) string {
+	switch {
+	case tr.TripID != "":
+		return "trip:" + tr.TripID
+	case tr.RequestID != "":
+		return "request:" + tr.RequestID
+	default:
+		return ""
+	}
+}
+
+type MemoryStore struct {
+	mu          sync.RWMutex
+	trips       map[Source][]*uber.Trip
+	keys        map[Source]map[string]bool
+	checkpoints map[Source]*Checkpoint
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		trips:       make(map[Source][]*uber.Trip),
+		keys:        make(map[Source]map[string]bool),
+		checkpoints: make(map[Source]*Checkpoint),
+	}
+}
+
+func (ms *MemoryStore) PutTrips(source Source, trips []*uber.Trip) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	keys := ms.keys[source]
+	if keys == nil {
+		keys = make(map[string]bool)
+		ms.keys[source] = keys
+	}
+	added := 0
+	for _, trip := range trips {
+		if trip == nil {
+			continue
+		}
+		key := tripKey(trip)
+		if key != "" && keys[key] {
+			continue
+		}
+		if key != "" {
+			keys[key] = true
+		}
+		ms.trips[source] = append(ms.trips[source], trip)
+		added += 1
+	}
+	return added, nil
+}
+
+func (ms *MemoryStore) Trips(source Source) ([]*uber.Trip, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return append([]*uber.Trip(nil), ms.trips[source]...), nil
+}
+
+func (ms *MemoryStore) Checkpoint(source Source) (*Checkpoint, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	cp, ok := ms.checkpoints[source]
+	if !ok {
+		return nil, nil
+	}
+	copied := *cp
+	return &copied, nil
+}
+
+func (ms *MemoryStore) SetCheckpoint(cp *Checkpoint) error {
+	if cp == nil {
+		return errNilCheckpoint
+	}
+	copied := *cp
+
+	ms.mu.Lock()
+	ms.checkpoints[cp.Source] = &copied
+	ms.mu.Unlock()
+	return nil
+}
+
+// FileStore stores the trips of every source as JSON lines in
+// "<source>.jsonl" and the checkpoints in "checkpoints.json",
+// all in the same directory.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+
+	// keys caches the keys of the stored trips, per source.
+	keys map[Source]map[string]bool
+}
+
+var _ Store = (*FileStore)(nil)
+
+const checkpointsFilename = "checkpoints.json"
+
+// OpenFileStore returns a FileStore backed by dir,
+// creating the directory if it doesn't exist.
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, keys: make(map[Source]map[string]bool)}, nil
+}
+
+func (fs *FileStore) tripsPath(source Source) string {
+	return filepath.Join(fs.dir, fmt.Sprintf("%s.jsonl", source))
+}
+
+// readTrips returns the stored trips of source and the size of the
+// file up to the last complete line. A final line that is incomplete or
+// cannot be parsed, as left by a write interrupted by a crash, is ignored
+// while such a line anywhere else means that the file is corrupted.
+func (fs *FileStore) readTrips(source Source) (trips []*uber.Trip, validSize int64, err error) {
+	f, err := os.Open(fs.tripsPath(source))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		if len(line) == 0 {
+			return trips, validSize, nil
+		}
+		complete := line[len(line)-1] == '\n'
+		trip := new(uber.Trip)
+		if perr := json.Unmarshal(line, trip); perr != nil || !complete {
+			if _, peekErr := br.Peek(1); errors.Is(peekErr, io.EOF) {
+				// The final line was torn.
+				return trips, validSize, nil
+			}
+			return nil, 0, fmt.Errorf("%s:%d: %v", fs.tripsPath(source), lineNumber, perr)
+		}
+		trips = append(trips, trip)
+		validSize += int64(len(line))
+	}
+}
+
+// loadKeys returns the keys of the stored trips of source,
+// reading them from disk the first time around. A torn final
+// line is then truncated so that trips can be appended again.
+func (fs *FileStore) loadKeys(source Source) (map[string]bool, error) {
+	if keys, ok := fs.keys[source]; ok {
+		return keys, nil
+	}
+	trips, validSize, err := fs.readTrips(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := truncateFile(fs.tripsPath(source), validSize); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, trip := range trips {
+		if key := tripKey(trip); key != "" {
+			keys[key] = true
+		}
+	}
+	fs.keys[source] = keys
+	return keys, nil
+}
+
+// truncateFile truncates the file at path to size if it is any larger.
+func truncateFile(path string, size int64) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if fi.Size() <= size {
+		return nil
+	}
+	return os.Truncate(path, size)
+}
+
+func (fs *FileStore) PutTrips(source Source, trips []*uber.Trip) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	keys, err := fs.loadKeys(source)
+	if err != nil {
+		return 0, err
+	}
+
+	var newKeys []string
+	var lines []byte
+	added := 0
+	for _, trip := range trips {
+		if trip == nil {
+			continue
+		}
+		key := tripKey(trip)
+		if key != "" && keys[key] {
+			continue
+		}
+		blob, err := json.Marshal(trip)
+		if err != nil {
+			return 0, err
+		}
+		lines = append(lines, blob...)
+		lines = append(lines, '\n')
+		added += 1
+		if key != "" {
+			keys[key] = true
+			newKeys = append(newKeys, key)
+		}
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	if err := appendToFile(fs.tripsPath(source), lines); err != nil {
+		// Forget the keys of the trips that weren't stored.
+		for _, key := range newKeys {
+			delete(keys, key)
+		}
+		return 0, err
+	}
+	return added, nil
+}
+
+// appendToFile appends blob to the file at path. If the write fails,
+// the file is truncated back so as not to leave a partial line.
+func appendToFile(path string, blob []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Truncate(fi.Size())
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (fs *FileStore) Trips(source Source) ([]*uber.Trip, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	trips, _, err := fs.readTrips(source)
+	return trips, err
+}
+
+func (fs *FileStore) readCheckpoints() (map[Source]*Checkpoint, error) {
+	checkpoints := make(map[Source]*Checkpoint)
+	blob, err := os.ReadFile(filepath.Join(fs.dir, checkpointsFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkpoints, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(blob, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+func (fs *FileStore) Checkpoint(source Source) (*Checkpoint, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	checkpoints, err := fs.readCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+	return checkpoints[source], nil
+}
+
+func (fs *FileStore) SetCheckpoint(cp *Checkpoint) error {
+	if cp == nil {
+		return errNilCheckpoint
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	checkpoints, err := fs.readCheckpoints()
+	if err != nil {
+		return err
+	}
+	checkpoints[cp.Source] = cp
+	blob, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash
+	// cannot leave behind truncated checkpoints.
+	tmp, err := os.CreateTemp(fs.dir, checkpointsFilename+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(fs.dir, checkpointsFilename))
+}
@@ -0,0 +1,190 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ubersync incrementally copies the trips of a rider
+// or of a driver into a Store, only retrieving the trips that
+// were not synced by the previous runs.
+package ubersync
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/orijtech/uber/v1"
+)
+
+type Source string
+
+const (
+	// SourceHistory is the history of the rider, see uber.Client.ListHistory.
+	SourceHistory Source = "history"
+
+	// SourceDriverTrips is the trips of the driver, see uber.Client.ListDriverTrips.
+	SourceDriverTrips Source = "driver_trips"
+)
+
+// Checkpoint records the newest trip synced from a source.
+type Checkpoint struct {
+	Source Source `json:"source"`
+
+	// StartTimeUnix is the start time of the newest trip.
+	StartTimeUnix int64  `json:"start_time"`
+	TripID        string `json:"trip_id,omitempty"`
+	RequestID     string `json:"request_id,omitempty"`
+
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+type Syncer struct {
+	Client *uber.Client
+	Store  Store
+
+	// LimitPerPage is the number of trips to retrieve per page.
+	// If unset, the defaults of the listing endpoints apply.
+	LimitPerPage int `json:"limit_per_page,omitempty"`
+
+	// Throttle is the minimum duration between the retrieval
+	// of two consecutive pages, see uber.NoThrottle.
+	Throttle time.Duration `json:"throttle,omitempty"`
+}
+
+type Result struct {
+	Source Source `json:"source"`
+
+	// Fetched is the number of trips retrieved from Uber.
+	Fetched int `json:"fetched"`
+
+	// Added is the number of trips that weren't already stored.
+	Added int `json:"added"`
+
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+}
+
+var (
+	errNilClient     = errors.New("expecting a non-nil client")
+	errNilStore      = errors.New("expecting a non-nil store")
+	errUnknownSource = errors.New("unknown source")
+)
+
+// Sync retrieves the trips of source that started at or after its
+// checkpoint and stores them. The checkpoint is only moved forward
+// once all the new trips were stored, so that a failed run is
+// resumed by the next one. Trips that were already stored are skipped.
+func (s *Syncer) Sync(ctx context.Context, source Source) (*Result, error) {
+	if s.Client == nil {
+		return nil, errNilClient
+	}
+	if s.Store == nil {
+		return nil, errNilStore
+	}
+
+	cp, err := s.Store.Checkpoint(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var trips iter.Seq2[*uber.Trip, error]
+	switch source {
+	case SourceHistory:
+		p, err := s.Client.HistoryPaginator(&uber.Pager{
+			LimitPerPage:     int64(s.LimitPerPage),
+			ThrottleDuration: s.Throttle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		trips = p.All(ctx)
+	case SourceDriverTrips:
+		dpq := &uber.DriverInfoQuery{LimitPerPage: s.LimitPerPage, Throttle: s.Throttle}
+		if cp != nil && cp.StartTimeUnix > 0 {
+			since := time.Unix(cp.StartTimeUnix, 0)
+			dpq.StartDate = &since
+		}
+		p, err := s.Client.DriverTripsPaginator(dpq)
+		if err != nil {
+			return nil, err
+		}
+		trips = p.All(ctx)
+	default:
+		return nil, errUnknownSource
+	}
+
+	res := &Result{Source: source}
+	newest := cp
+	var batch []*uber.Trip
+	flush := func() error {
+		added, err := s.Store.PutTrips(source, batch)
+		res.Added += added
+		batch = batch[:0]
+		return err
+	}
+
+	for trip, err := range trips {
+		if err != nil {
+			// Keep what was retrieved, the next run will skip it.
+			if ferr := flush(); ferr != nil {
+				return res, ferr
+			}
+			return res, err
+		}
+		// Null elements of a page are decoded as nil trips.
+		if trip == nil {
+			continue
+		}
+
+		var startTimeUnix int64
+		if startTime := trip.StartTime(); !startTime.IsZero() {
+			startTimeUnix = startTime.Unix()
+		}
+		if source == SourceHistory && cp != nil && startTimeUnix > 0 && startTimeUnix < cp.StartTimeUnix {
+			// The history is listed from the most recent trip hence
+			// the trips that follow were synced by a previous run.
+			break
+		}
+
+		res.Fetched += 1
+		batch = append(batch, trip)
+		if newest == nil || startTimeUnix > newest.StartTimeUnix {
+			newest = &Checkpoint{
+				Source:        source,
+				StartTimeUnix: startTimeUnix,
+				TripID:        trip.TripID,
+				RequestID:     trip.RequestID,
+			}
+		}
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return res, err
+	}
+
+	if newest == nil {
+		newest = &Checkpoint{Source: source}
+	}
+	next := *newest
+	next.SyncedAt = time.Now().UTC()
+	if err := s.Store.SetCheckpoint(&next); err != nil {
+		return res, err
+	}
+	res.Checkpoint = &next
+	return res, nil
+}
+
+const batchSize = 100
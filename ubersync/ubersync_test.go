@@ -0,0 +1,312 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ubersync_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/uber/ubersync"
+	"github.com/orijtech/uber/v1"
+)
+
+// historyBackend serves a rider history listed from the most recent trip.
+type historyBackend struct {
+	mu       sync.Mutex
+	trips    []*uber.Trip
+	requests int
+	fromTime string
+}
+
+func (hb *historyBackend) prepend(trips ...*uber.Trip) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	hb.trips = append(trips, hb.trips...)
+}
+
+func (hb *historyBackend) RoundTrip(req *http.Request) (*http.Response, error) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	hb.requests += 1
+	query := req.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = 2
+	}
+	hb.fromTime = query.Get("from_time")
+
+	var page []*uber.Trip
+	if offset < len(hb.trips) {
+		page = hb.trips[offset:min(offset+limit, len(hb.trips))]
+	}
+	wrap := map[string]interface{}{"count": len(hb.trips), "limit": limit, "offset": offset}
+	if strings.HasSuffix(req.URL.Path, "/partners/trips") {
+		wrap["trips"] = page
+	} else {
+		wrap["history"] = page
+	}
+	blob, err := json.Marshal(wrap)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewReader(blob)),
+	}, nil
+}
+
+func makeTrips(ids ...int) []*uber.Trip {
+	var trips []*uber.Trip
+	for _, id := range ids {
+		trips = append(trips, &uber.Trip{
+			TripID:        fmt.Sprintf("trip-%d", id),
+			StartTimeUnix: int64(1500000000 + id*1000),
+			Status:        uber.StatusCompleted,
+		})
+	}
+	return trips
+}
+
+func tripIDs(trips []*uber.Trip) map[string]bool {
+	ids := make(map[string]bool)
+	for _, trip := range trips {
+		ids[trip.TripID] = true
+	}
+	return ids
+}
+
+func TestSyncHistory(t *testing.T) {
+	fileStore, err := ubersync.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("opening the file store: %v", err)
+	}
+
+	stores := [...]ubersync.Store{
+		0: ubersync.NewMemoryStore(),
+		1: fileStore,
+	}
+
+	for i, store := range stores {
+		backend := &historyBackend{trips: makeTrips(5, 4, 3, 2, 1)}
+		client, err := uber.NewClient("test-token")
+		if err != nil {
+			t.Fatalf("#%d: initializing client: %v", i, err)
+		}
+		client.SetHTTPRoundTripper(backend)
+
+		syncer := &ubersync.Syncer{Client: client, Store: store, LimitPerPage: 2, Throttle: uber.NoThrottle}
+		res, err := syncer.Sync(context.Background(), ubersync.SourceHistory)
+		if err != nil {
+			t.Fatalf("#%d: first sync: %v", i, err)
+		}
+		if res.Fetched != 5 || res.Added != 5 {
+			t.Errorf("#%d: first sync: got fetched=%d added=%d want 5 and 5", i, res.Fetched, res.Added)
+		}
+		if g, w := res.Checkpoint.TripID, "trip-5"; g != w {
+			t.Errorf("#%d: checkpoint: got tripID=%q want=%q", i, g, w)
+		}
+
+		// Only the trips since the last run must be retrieved.
+		backend.prepend(makeTrips(7, 6)...)
+		backend.requests = 0
+		res, err = syncer.Sync(context.Background(), ubersync.SourceHistory)
+		if err != nil {
+			t.Fatalf("#%d: second sync: %v", i, err)
+		}
+		if g, w := res.Added, 2; g != w {
+			t.Errorf("#%d: second sync: added: got=%d want=%d", i, g, w)
+		}
+		if g, w := backend.requests, 2; g != w {
+			t.Errorf("#%d: second sync: requests: got=%d want=%d", i, g, w)
+		}
+		if g, w := res.Checkpoint.TripID, "trip-7"; g != w {
+			t.Errorf("#%d: checkpoint: got tripID=%q want=%q", i, g, w)
+		}
+
+		stored, err := store.Trips(ubersync.SourceHistory)
+		if err != nil {
+			t.Fatalf("#%d: retrieving the stored trips: %v", i, err)
+		}
+		if g, w := len(stored), 7; g != w {
+			t.Errorf("#%d: stored trips: got=%d want=%d", i, g, w)
+		}
+		if ids := tripIDs(stored); len(ids) != len(stored) {
+			t.Errorf("#%d: duplicate trips were stored: %v", i, ids)
+		}
+	}
+}
+
+func TestSyncSkipsNilTrips(t *testing.T) {
+	sources := [...]ubersync.Source{
+		0: ubersync.SourceHistory,
+		1: ubersync.SourceDriverTrips,
+	}
+
+	for i, source := range sources {
+		// The nil trip is served as null.
+		backend := &historyBackend{trips: append([]*uber.Trip{nil}, makeTrips(2, 1)...)}
+		client, err := uber.NewClient("test-token")
+		if err != nil {
+			t.Fatalf("#%d: initializing client: %v", i, err)
+		}
+		client.SetHTTPRoundTripper(backend)
+
+		store := ubersync.NewMemoryStore()
+		syncer := &ubersync.Syncer{Client: client, Store: store, LimitPerPage: 2, Throttle: uber.NoThrottle}
+		res, err := syncer.Sync(context.Background(), source)
+		if err != nil {
+			t.Errorf("#%d: sync: %v", i, err)
+			continue
+		}
+		if res.Fetched != 2 || res.Added != 2 {
+			t.Errorf("#%d: got fetched=%d added=%d want 2 and 2", i, res.Fetched, res.Added)
+		}
+		if g, w := res.Checkpoint.TripID, "trip-2"; g != w {
+			t.Errorf("#%d: checkpoint: got tripID=%q want=%q", i, g, w)
+		}
+	}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := ubersync.OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("opening the file store: %v", err)
+	}
+	if added, err := store.PutTrips(ubersync.SourceDriverTrips, makeTrips(1, 2, 2)); err != nil || added != 2 {
+		t.Fatalf("PutTrips: got added=%d err=%v want 2 and nil", added, err)
+	}
+	cp := &ubersync.Checkpoint{Source: ubersync.SourceDriverTrips, StartTimeUnix: 1500002000, TripID: "trip-2"}
+	if err := store.SetCheckpoint(cp); err != nil {
+		t.Fatalf("SetCheckpoint: %v", err)
+	}
+
+	reopened, err := ubersync.OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopening the file store: %v", err)
+	}
+	if added, err := reopened.PutTrips(ubersync.SourceDriverTrips, makeTrips(2, 3)); err != nil || added != 1 {
+		t.Errorf("PutTrips after reopening: got added=%d err=%v want 1 and nil", added, err)
+	}
+	gotCp, err := reopened.Checkpoint(ubersync.SourceDriverTrips)
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if gotCp == nil || gotCp.TripID != cp.TripID || gotCp.StartTimeUnix != cp.StartTimeUnix {
+		t.Errorf("checkpoint: got=%#v want=%#v", gotCp, cp)
+	}
+	if none, err := reopened.Checkpoint(ubersync.SourceHistory); err != nil || none != nil {
+		t.Errorf("unsynced source: got checkpoint=%#v err=%v want nil and nil", none, err)
+	}
+
+	// Driver trips are retrieved since the checkpoint.
+	backend := &historyBackend{trips: makeTrips(4, 3)}
+	client, err := uber.NewClient("test-token")
+	if err != nil {
+		t.Fatalf("initializing client: %v", err)
+	}
+	client.SetHTTPRoundTripper(backend)
+	syncer := &ubersync.Syncer{Client: client, Store: reopened, Throttle: uber.NoThrottle}
+	res, err := syncer.Sync(context.Background(), ubersync.SourceDriverTrips)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if g, w := backend.fromTime, "1500002000"; g != w {
+		t.Errorf("from_time: got=%q want=%q", g, w)
+	}
+	if g, w := res.Added, 1; g != w {
+		t.Errorf("added: got=%d want=%d", g, w)
+	}
+	stored, err := reopened.Trips(ubersync.SourceDriverTrips)
+	if err != nil {
+		t.Fatalf("Trips: %v", err)
+	}
+	if g, w := len(stored), 4; g != w {
+		t.Errorf("stored trips: got=%d want=%d", g, w)
+	}
+}
+
+func TestFileStoreTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	store, err := ubersync.OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("opening the file store: %v", err)
+	}
+	if added, err := store.PutTrips(ubersync.SourceHistory, makeTrips(1, 2)); err != nil || added != 2 {
+		t.Fatalf("PutTrips: got added=%d err=%v want 2 and nil", added, err)
+	}
+
+	// A crash while appending leaves a partial last line.
+	path := filepath.Join(dir, "history.jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("opening the trips file: %v", err)
+	}
+	if _, err := f.WriteString(`{"trip_id":"trip-3","start_ti`); err != nil {
+		t.Fatalf("writing a partial line: %v", err)
+	}
+	f.Close()
+
+	reopened, err := ubersync.OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopening the file store: %v", err)
+	}
+	stored, err := reopened.Trips(ubersync.SourceHistory)
+	if err != nil {
+		t.Fatalf("Trips with a partial last line: %v", err)
+	}
+	if g, w := len(stored), 2; g != w {
+		t.Errorf("stored trips: got=%d want=%d", g, w)
+	}
+
+	if added, err := reopened.PutTrips(ubersync.SourceHistory, makeTrips(2, 3)); err != nil || added != 1 {
+		t.Fatalf("PutTrips after a partial line: got added=%d err=%v want 1 and nil", added, err)
+	}
+	stored, err = reopened.Trips(ubersync.SourceHistory)
+	if err != nil {
+		t.Fatalf("Trips: %v", err)
+	}
+	if g, w := tripIDs(stored), tripIDs(makeTrips(1, 2, 3)); !reflect.DeepEqual(g, w) || len(stored) != 3 {
+		t.Errorf("stored trips: got=%v want=%v", g, w)
+	}
+
+	// Corruption before the last line is still reported.
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the trips file: %v", err)
+	}
+	corrupted := append([]byte("not json\n"), blob...)
+	if err := os.WriteFile(path, corrupted, 0644); err != nil {
+		t.Fatalf("corrupting the trips file: %v", err)
+	}
+	if _, err := reopened.Trips(ubersync.SourceHistory); err == nil {
+		t.Errorf("expecting an error for a corrupted line in the middle")
+	}
+}
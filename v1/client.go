@@ -15,14 +15,13 @@
 package uber
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
 
@@ -40,6 +39,10 @@ type Client struct {
 	rt        http.RoundTripper
 	token     string
 	sandboxed bool
+
+	retryPolicy *RetryPolicy
+	ridePolicy  *RidePolicy
+	rateLimits  rateLimiter
 }
 
 func (c *Client) hasServerToken() bool {
@@ -189,10 +192,47 @@ func (c *Client) doReq(req *http.Request) ([]byte, http.Header, error) {
 }
 
 func (c *Client) doHTTPReq(req *http.Request) ([]byte, http.Header, error) {
+	rp := c.retryPolicyOrNil()
+	maxAttempts := rp.maxAttempts()
+
+	for attempt := 1; ; attempt++ {
+		blob, hdr, statusCode, err := c.doHTTPReqOnce(req)
+		if attempt >= maxAttempts || !shouldRetry(req, statusCode, err) {
+			return blob, hdr, err
+		}
+
+		nextReq, rerr := rewindRequest(req)
+		if rerr != nil {
+			return blob, hdr, err
+		}
+
+		select {
+		case <-req.Context().Done():
+			return blob, hdr, err
+		case <-time.After(rp.backoff(attempt, hdr)):
+		}
+		req = nextReq
+	}
+}
+
+// doHTTPReqOnce sends req exactly once, additionally returning the
+// status code of the response or 0 if no response was received.
+func (c *Client) doHTTPReqOnce(req *http.Request) ([]byte, http.Header, int, error) {
+	// Fail fast if the caller already gave up on this request.
+	if err := req.Context().Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	rateLimitKey := rateLimitKey(req)
+	if err := c.rateLimits.reserve(req.Context(), rateLimitKey); err != nil {
+		return nil, nil, 0, err
+	}
+
 	res, err := c.httpClient().Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
+	c.rateLimits.update(rateLimitKey, res.StatusCode, res.Header)
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
@@ -203,9 +243,7 @@ func (c *Client) doHTTPReq(req *http.Request) ([]byte, http.Header, error) {
 		if res.Body != nil {
 			slurp, _ := ioutil.ReadAll(res.Body)
 			if len(slurp) > 3 {
-				ue := new(Error)
-				plainUE := new(Error)
-				if jerr := json.Unmarshal(slurp, ue); jerr == nil && !reflect.DeepEqual(ue, plainUE) {
+				if ue := parseError(slurp, res.StatusCode); ue != nil {
 					err = ue
 				} else {
 					errMsg = string(slurp)
@@ -215,11 +253,11 @@ func (c *Client) doHTTPReq(req *http.Request) ([]byte, http.Header, error) {
 		if err == nil {
 			err = otils.MakeCodedError(errMsg, res.StatusCode)
 		}
-		return nil, res.Header, err
+		return nil, res.Header, res.StatusCode, err
 	}
 
 	blob, err := ioutil.ReadAll(res.Body)
-	return blob, res.Header, err
+	return blob, res.Header, res.StatusCode, err
 }
 
 func NewClientFromOAuth2Token(token *oauth2.Token) (*Client, error) {
@@ -0,0 +1,196 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type CollectOptions struct {
+	// MaxItems if set is the maximum number of items
+	// to collect. Paging stops as soon as it is reached.
+	MaxItems int `json:"max_items,omitempty"`
+
+	// Since if set only keeps the items that
+	// happened at or after this time.
+	Since time.Time `json:"since,omitempty"`
+
+	// Until if set only keeps the items
+	// that happened before this time.
+	Until time.Time `json:"until,omitempty"`
+}
+
+// PartialResultError is returned by the collecting methods
+// when retrieving a page failed. The items collected from
+// the preceding pages are returned alongside it.
+type PartialResultError struct {
+	// PageNumber is the 0-based number of the page that failed.
+	PageNumber int `json:"page_number"`
+
+	// Offset is the offset of the page that failed.
+	Offset int64 `json:"offset"`
+
+	Err error `json:"-"`
+}
+
+var _ error = (*PartialResultError)(nil)
+
+func (pre *PartialResultError) Error() string {
+	return fmt.Sprintf("page #%d at offset %d: %v", pre.PageNumber, pre.Offset, pre.Err)
+}
+
+func (pre *PartialResultError) Unwrap() error { return pre.Err }
+
+func (copts *CollectOptions) hasTimeRange() bool {
+	return copts != nil && (!copts.Since.IsZero() || !copts.Until.IsZero())
+}
+
+// inTimeRange reports whether an item that happened at t is
+// kept. Items whose time is unknown are dropped if a range is set.
+func (copts *CollectOptions) inTimeRange(t time.Time) bool {
+	if !copts.hasTimeRange() {
+		return true
+	}
+	if t.IsZero() {
+		return false
+	}
+	if !copts.Since.IsZero() && t.Before(copts.Since) {
+		return false
+	}
+	if !copts.Until.IsZero() && !t.Before(copts.Until) {
+		return false
+	}
+	return true
+}
+
+func (copts *CollectOptions) maxItems() int {
+	if copts == nil {
+		return 0
+	}
+	return copts.MaxItems
+}
+
+// AllHistory retrieves the trips in the rider's history, as
+// configured by threq which can be nil, and filtered by opts.
+func (c *Client) AllHistory(ctx context.Context, threq *Pager, opts *CollectOptions) ([]*Trip, error) {
+	p, err := c.HistoryPaginator(threq)
+	if err != nil {
+		return nil, err
+	}
+	return collect(ctx, p, opts, (*Trip).StartTime)
+}
+
+// AllDriverTrips retrieves the trips of the driver, as configured by
+// dpq which can be nil, and filtered by opts. The time range of opts
+// is also used for dpq.StartDate and dpq.EndDate if those are unset.
+func (c *Client) AllDriverTrips(ctx context.Context, dpq *DriverInfoQuery, opts *CollectOptions) ([]*Trip, error) {
+	p, err := c.DriverTripsPaginator(opts.narrowDriverQuery(dpq))
+	if err != nil {
+		return nil, err
+	}
+	return collect(ctx, p, opts, (*Trip).StartTime)
+}
+
+// AllDriverPayments retrieves the payments of the driver, as configured
+// by dpq which can be nil, and filtered by opts. The time range of opts
+// is also used for dpq.StartDate and dpq.EndDate if those are unset.
+func (c *Client) AllDriverPayments(ctx context.Context, dpq *DriverInfoQuery, opts *CollectOptions) ([]*Payment, error) {
+	p, err := c.DriverPaymentsPaginator(opts.narrowDriverQuery(dpq))
+	if err != nil {
+		return nil, err
+	}
+	return collect(ctx, p, opts, (*Payment).eventTime)
+}
+
+// AllPriceEstimates retrieves the price estimates for ereq. Estimates
+// are not timestamped hence only opts.MaxItems applies to them.
+func (c *Client) AllPriceEstimates(ctx context.Context, ereq *EstimateRequest, opts *CollectOptions) ([]*PriceEstimate, error) {
+	p, err := c.PriceEstimatesPaginator(ereq)
+	if err != nil {
+		return nil, err
+	}
+	return collect(ctx, p, opts.withoutTimeRange(), nil)
+}
+
+// AllTimeEstimates retrieves the time estimates for ereq. Estimates
+// are not timestamped hence only opts.MaxItems applies to them.
+func (c *Client) AllTimeEstimates(ctx context.Context, ereq *EstimateRequest, opts *CollectOptions) ([]*TimeEstimate, error) {
+	p, err := c.TimeEstimatesPaginator(ereq)
+	if err != nil {
+		return nil, err
+	}
+	return collect(ctx, p, opts.withoutTimeRange(), nil)
+}
+
+func (copts *CollectOptions) withoutTimeRange() *CollectOptions {
+	return &CollectOptions{MaxItems: copts.maxItems()}
+}
+
+func (copts *CollectOptions) narrowDriverQuery(dpq *DriverInfoQuery) *DriverInfoQuery {
+	narrowed := new(DriverInfoQuery)
+	if dpq != nil {
+		*narrowed = *dpq
+	}
+	if copts == nil {
+		return narrowed
+	}
+	if narrowed.StartDate == nil && !copts.Since.IsZero() {
+		since := copts.Since
+		narrowed.StartDate = &since
+	}
+	if narrowed.EndDate == nil && !copts.Until.IsZero() {
+		until := copts.Until
+		narrowed.EndDate = &until
+	}
+	return narrowed
+}
+
+// collect flattens the pages of p into a slice, keeping only the
+// items whose time, as reported by timeOf, is in the range of opts.
+// Nil items, decoded from null elements, are skipped.
+func collect[E any](ctx context.Context, p *Paginator[*E], opts *CollectOptions, timeOf func(*E) time.Time) ([]*E, error) {
+	maxItems := opts.maxItems()
+	var items []*E
+	for {
+		pageNumber, offset := p.position()
+		page, err := p.Next(ctx)
+		if errors.Is(err, ErrNoMorePages) {
+			return items, nil
+		}
+		if err != nil {
+			return items, &PartialResultError{PageNumber: pageNumber, Offset: offset, Err: err}
+		}
+
+		for _, item := range page.Items {
+			if item == nil {
+				continue
+			}
+			if timeOf != nil && !opts.inTimeRange(timeOf(item)) {
+				continue
+			}
+			items = append(items, item)
+			if maxItems > 0 && len(items) >= maxItems {
+				return items, nil
+			}
+		}
+	}
+}
+
+func (p *Payment) eventTime() time.Time {
+	return unixTime(int64(p.EventTime))
+}
@@ -0,0 +1,301 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxConcurrentFareLookups is the maximum number of
+// upfront fares that CompareProducts looks up at once.
+const maxConcurrentFareLookups = 5
+
+// ProductQuote merges what Uber estimates about a product for a trip.
+type ProductQuote struct {
+	ProductID     string `json:"product_id"`
+	Name          string `json:"display_name"`
+	LocalizedName string `json:"localized_display_name"`
+
+	// Capacity is the number of people that can be
+	// accomodated by the product, 0 if it is unknown.
+	Capacity int `json:"capacity,omitempty"`
+
+	Price       *PriceEstimate `json:"price,omitempty"`
+	Time        *TimeEstimate  `json:"time,omitempty"`
+	UpfrontFare *UpfrontFare   `json:"upfront_fare,omitempty"`
+
+	// UpfrontFareErr is the error encountered looking up the
+	// upfront fare of the product. Some products such as taxis
+	// do not have upfront fares and are not looked up at all.
+	UpfrontFareErr error `json:"-"`
+}
+
+// CompareProducts looks up concurrently the price and time estimates
+// of the products available for the trip described by ereq, and then
+// the upfront fare of each product. It returns a quote per product,
+// in the order in which Uber listed their price estimates, and at most
+// ereq.MaxProducts of them if set.
+//
+// An error is returned if the estimates could not be retrieved. If only
+// some of their pages could be retrieved, the quotes for the estimates
+// retrieved before the failure are returned alongside the resulting
+// *PartialResultError. Failing to retrieve an upfront fare is instead
+// reported by its quote.
+func (c *Client) CompareProducts(ctx context.Context, ereq *EstimateRequest) ([]*ProductQuote, error) {
+	if ereq == nil {
+		return nil, errNilEstimateRequest
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := &CollectOptions{MaxItems: ereq.MaxProducts}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		partials []error
+
+		prices   []*PriceEstimate
+		times    []*TimeEstimate
+		products []*Product
+	)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn()
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// Partial results are still merged hence
+			// the other lookups are left to complete.
+			if pre := new(PartialResultError); errors.As(err, &pre) {
+				partials = append(partials, err)
+			} else if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		}()
+	}
+	run(func() (err error) {
+		prices, err = c.AllPriceEstimates(ctx, ereq, opts)
+		return err
+	})
+	run(func() (err error) {
+		times, err = c.AllTimeEstimates(ctx, ereq, opts)
+		return err
+	})
+	// Products are listed only for their capacity
+	// which is why they are skipped without coordinates.
+	if ereq.StartLatitude != 0 || ereq.StartLongitude != 0 {
+		run(func() (err error) {
+			place := &Place{Latitude: ereq.StartLatitude, Longitude: ereq.StartLongitude}
+			products, err = c.ListProductsContext(ctx, place)
+			return err
+		})
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	quotes := mergeQuotes(prices, times, products)
+	if ereq.MaxProducts > 0 && len(quotes) > ereq.MaxProducts {
+		quotes = quotes[:ereq.MaxProducts]
+	}
+	c.lookupUpfrontFares(ctx, ereq, quotes, products)
+	if len(partials) > 0 {
+		return quotes, errors.Join(partials...)
+	}
+	return quotes, nil
+}
+
+func mergeQuotes(prices []*PriceEstimate, times []*TimeEstimate, products []*Product) []*ProductQuote {
+	var quotes []*ProductQuote
+	byID := make(map[string]*ProductQuote)
+	quoteFor := func(productID, name, localizedName string) *ProductQuote {
+		quote, ok := byID[productID]
+		if !ok {
+			quote = &ProductQuote{ProductID: productID, Name: name, LocalizedName: localizedName}
+			byID[productID] = quote
+			quotes = append(quotes, quote)
+		}
+		return quote
+	}
+	for _, pe := range prices {
+		if pe != nil {
+			quoteFor(pe.ProductID, pe.Name, pe.LocalizedName).Price = pe
+		}
+	}
+	for _, te := range times {
+		if te != nil {
+			quoteFor(te.ProductID, te.Name, te.LocalizedName).Time = te
+		}
+	}
+	for _, product := range products {
+		if product == nil {
+			continue
+		}
+		if quote, ok := byID[product.ID]; ok {
+			quote.Capacity = product.Capacity
+		}
+	}
+	return quotes
+}
+
+func (c *Client) lookupUpfrontFares(ctx context.Context, ereq *EstimateRequest, quotes []*ProductQuote, products []*Product) {
+	noUpfrontFares := make(map[string]bool)
+	for _, product := range products {
+		if product != nil && !product.UpfrontFareEnabled {
+			noUpfrontFares[product.ID] = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan bool, maxConcurrentFareLookups)
+	for _, quote := range quotes {
+		if noUpfrontFares[quote.ProductID] {
+			continue
+		}
+		wg.Add(1)
+		go func(quote *ProductQuote) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+
+			fareReq := &EstimateRequest{
+				StartLatitude:  ereq.StartLatitude,
+				StartLongitude: ereq.StartLongitude,
+				EndLatitude:    ereq.EndLatitude,
+				EndLongitude:   ereq.EndLongitude,
+				StartPlace:     ereq.StartPlace,
+				EndPlace:       ereq.EndPlace,
+				SeatCount:      ereq.SeatCount,
+				ProductID:      quote.ProductID,
+			}
+			quote.UpfrontFare, quote.UpfrontFareErr = c.UpfrontFareContext(ctx, fareReq)
+		}(quote)
+	}
+	wg.Wait()
+}
+
+// Fare returns the upfront fare of the product if known, otherwise
+// the low end of its price estimate. ok is false if neither is known.
+func (pq *ProductQuote) Fare() (fare Money, ok bool) {
+	if upf := pq.UpfrontFare; upf != nil && upf.Fare != nil {
+		return upf.Fare.Money(), true
+	}
+	if pe := pq.Price; pe != nil && pe.LowEstimate > 0 {
+		return pe.LowMoney(), true
+	}
+	return Money{}, false
+}
+
+// PickupETA returns how long it will take for a vehicle to
+// arrive. ok is false if Uber did not estimate it.
+func (pq *ProductQuote) PickupETA() (eta time.Duration, ok bool) {
+	if te := pq.Time; te != nil && te.ETASeconds > 0 {
+		return time.Duration(float64(te.ETASeconds) * float64(time.Second)), true
+	}
+	if upf := pq.UpfrontFare; upf != nil && upf.PickupEstimateMinutes > 0 {
+		return time.Duration(float64(upf.PickupEstimateMinutes) * float64(time.Minute)), true
+	}
+	return 0, false
+}
+
+// TripDuration returns how long the trip is estimated to last
+// once picked up. ok is false if Uber did not estimate it.
+func (pq *ProductQuote) TripDuration() (duration time.Duration, ok bool) {
+	if upf := pq.UpfrontFare; upf != nil && upf.Trip != nil && upf.Trip.DurationEstimate > 0 {
+		return time.Duration(float64(upf.Trip.DurationEstimate) * float64(time.Second)), true
+	}
+	if pe := pq.Price; pe != nil && pe.DurationSeconds > 0 {
+		return time.Duration(float64(pe.DurationSeconds) * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// ArrivalIn returns how long it will take to reach the destination,
+// pickup included. ok is false if either duration is unknown.
+func (pq *ProductQuote) ArrivalIn() (arrival time.Duration, ok bool) {
+	eta, etaOK := pq.PickupETA()
+	duration, durationOK := pq.TripDuration()
+	return eta + duration, etaOK && durationOK
+}
+
+// SurgeMultiplier returns the surge multiplier in effect
+// for the product, 1 if there is no surge.
+func (pq *ProductQuote) SurgeMultiplier() float64 {
+	if upf := pq.UpfrontFare; upf != nil && upf.Estimate != nil && upf.Estimate.SurgeMultiplier > 1 {
+		return float64(upf.Estimate.SurgeMultiplier)
+	}
+	if pe := pq.Price; pe != nil && pe.SurgeMultiplier > 1 {
+		return float64(pe.SurgeMultiplier)
+	}
+	return 1
+}
+
+// SortByFare sorts quotes from the cheapest to the most expensive.
+// Fares in different currencies are not comparable hence quotes are
+// first grouped by currency code. Quotes without a fare come last.
+func SortByFare(quotes []*ProductQuote) {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		fi, iok := quotes[i].Fare()
+		fj, jok := quotes[j].Fare()
+		if iok != jok {
+			return iok
+		}
+		if fi.CurrencyCode != fj.CurrencyCode {
+			return fi.CurrencyCode < fj.CurrencyCode
+		}
+		return fi.Amount.Cmp(fj.Amount) < 0
+	})
+}
+
+// SortByPickup sorts quotes from the fastest pickup to the
+// slowest. Quotes without a pickup estimate come last.
+func SortByPickup(quotes []*ProductQuote) {
+	sortQuotes(quotes, func(pq *ProductQuote) (float64, bool) {
+		eta, ok := pq.PickupETA()
+		return float64(eta), ok
+	})
+}
+
+// SortByArrival sorts quotes from the earliest arrival at the
+// destination to the latest. Quotes without estimates come last.
+func SortByArrival(quotes []*ProductQuote) {
+	sortQuotes(quotes, func(pq *ProductQuote) (float64, bool) {
+		arrival, ok := pq.ArrivalIn()
+		return float64(arrival), ok
+	})
+}
+
+// sortQuotes stably sorts quotes by the keys returned by keyOf.
+func sortQuotes(quotes []*ProductQuote, keyOf func(*ProductQuote) (float64, bool)) {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		ki, iok := keyOf(quotes[i])
+		kj, jok := keyOf(quotes[j])
+		if iok != jok {
+			return iok
+		}
+		return ki < kj
+	})
+}
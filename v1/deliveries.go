@@ -16,11 +16,14 @@ package uber
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -44,6 +47,13 @@ type DeliveryRequest struct {
 	// The details of the delivery pickup.
 	Pickup  *Endpoint `json:"pickup"`
 	Dropoff *Endpoint `json:"dropoff"`
+
+	// PromptOnQuote is an optional callback function that is
+	// used when QuoteID is blank. It is invoked with the cheapest
+	// unexpired quote for the delivery, to accept it before the
+	// courier is dispatched, by returning a nil error. The delivery
+	// is not requested if the quotes are in different currencies.
+	PromptOnQuote func(*DeliveryQuote) error `json:"-"`
 }
 
 type Item struct {
@@ -93,6 +103,10 @@ type Contact struct {
 	// Uber send SMS delivery notifications.
 	// This field is optional and defaults to true.
 	SendSMSNotifications bool `json:"send_sms_notifications,omitempty"`
+
+	// Location is the last known location of a courier.
+	// It is only set for Delivery.Courier.
+	Location *Location `json:"location,omitempty"`
 }
 
 type Phone struct {
@@ -106,7 +120,8 @@ type Delivery struct {
 	ID      string  `json:"delivery_id"`
 	Fee     float32 `json:"fee"`
 	QuoteID string  `json:"quote_id"`
-	Status  Status  `json:"status"`
+
+	Status DeliveryStatus `json:"status"`
 
 	Courier *Contact `json:"courier,omitempty"`
 
@@ -129,6 +144,14 @@ type Delivery struct {
 	Batch *Batch `json:"batch"`
 }
 
+// FeeMoney returns the fee of the delivery. The fee is
+// decoded as a float32 hence is rounded to its shortest
+// decimal representation, for example 5.1 not 5.0999999.
+func (d *Delivery) FeeMoney() Money {
+	amount, _ := ParseDecimal(strconv.FormatFloat(float64(d.Fee), 'f', -1, 32))
+	return NewMoney(amount, d.CurrencyCode)
+}
+
 type Batch struct {
 	// Unique identifier of the batch. Deliveries
 	// in the same batch share the same identifier.
@@ -142,75 +165,147 @@ type Batch struct {
 
 var (
 	errNilPickup  = errors.New("a non-nil pickup is required")
-	errNilDropoff = errors.New("a non-nil pickup is required")
+	errNilDropoff = errors.New("a non-nil dropoff is required")
 
 	errNilEndpointLocation = errors.New("a non-nil endpoint.location is required")
 	errNilEndpointContact  = errors.New("a non-nil endpoint.contact is required")
+	errBlankAddress        = errors.New("expecting either an address or coordinates")
 
 	errInvalidItems = errors.New("expecting at least one valid item")
+	errNilItem      = errors.New("expecting a non-nil item")
+
+	errTooLongOrderReferenceID = errors.New("expecting an order_reference_id of at most 256 characters")
+	errTooLongInstructions     = errors.New("expecting special_instructions of at most 256 characters")
 )
 
+const maxDeliveryFieldLength = 256
+
+// Validate checks every field of dr and returns a *ValidationError
+// that reports all of the invalid ones by their JSON path.
 func (dr *DeliveryRequest) Validate() error {
-	if dr == nil || dr.Pickup == nil {
-		return errNilPickup
+	if dr == nil {
+		dr = new(DeliveryRequest)
 	}
-	if err := dr.Pickup.Validate(); err != nil {
-		return err
+
+	v := new(validator)
+	if len([]rune(dr.OrderReferenceID)) > maxDeliveryFieldLength {
+		v.add("order_reference_id", errTooLongOrderReferenceID)
 	}
-	if err := dr.Dropoff.Validate(); err != nil {
-		return err
+	if len(dr.Items) == 0 {
+		v.add("items", errInvalidItems)
 	}
-	if !atLeastOneValidItem(dr.Items) {
-		return errInvalidItems
+	for i, item := range dr.Items {
+		if item == nil {
+			v.add(indexPath("items", i), errNilItem)
+			continue
+		}
+		item.validate(v, indexPath("items", i))
+	}
+	if dr.Pickup == nil {
+		v.add("pickup", errNilPickup)
+	} else {
+		dr.Pickup.validate(v, "pickup")
+	}
+	if dr.Dropoff == nil {
+		v.add("dropoff", errNilDropoff)
+	} else {
+		dr.Dropoff.validate(v, "dropoff")
 	}
-	return nil
+	return v.err()
 }
 
 var (
-	errInvalidQuantity = errors.New("quantity has to be > 0")
-	errBlankItemTitle  = errors.New("item title has to be non-empty")
+	errInvalidQuantity  = errors.New("quantity has to be > 0")
+	errBlankItemTitle   = errors.New("item title has to be non-empty")
+	errInvalidDimension = errors.New("dimensions have to be >= 0")
 )
 
 func (i *Item) Validate() error {
-	if i == nil || i.Quantity <= 0 {
+	if i == nil {
 		return errInvalidQuantity
 	}
-	if i.Title == "" {
-		return errBlankItemTitle
-	}
-	return nil
+	v := new(validator)
+	i.validate(v, "")
+	return v.err()
 }
 
-func atLeastOneValidItem(items []*Item) bool {
-	for _, item := range items {
-		if err := item.Validate(); err == nil {
-			return true
+func (i *Item) validate(v *validator, path string) {
+	if strings.TrimSpace(i.Title) == "" {
+		v.add(fieldPath(path, "title"), errBlankItemTitle)
+	}
+	if i.Quantity <= 0 {
+		v.add(fieldPath(path, "quantity"), errInvalidQuantity)
+	}
+	dimensions := [...]struct {
+		field string
+		value float32
+	}{
+		{"width", i.WidthInches},
+		{"height", i.HeightInches},
+		{"length", i.LengthInches},
+	}
+	for _, dim := range dimensions {
+		if value := float64(dim.value); value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			v.add(fieldPath(path, dim.field), errInvalidDimension)
+		}
+	}
+	if i.CurrencyCode != "" {
+		if err := i.CurrencyCode.Validate(); err != nil {
+			v.add(fieldPath(path, "currency_code"), err)
 		}
 	}
-	return false
 }
 
 func (e *Endpoint) Validate() error {
-	if e == nil || e.Location == nil {
+	if e == nil {
 		return errNilEndpointLocation
 	}
+	v := new(validator)
+	e.validate(v, "")
+	return v.err()
+}
+
+func (e *Endpoint) validate(v *validator, path string) {
+	if e.Location == nil {
+		v.add(fieldPath(path, "location"), errNilEndpointLocation)
+	} else {
+		locationPath := fieldPath(path, "location")
+		e.Location.validate(v, locationPath)
+		if strings.TrimSpace(e.Location.PrimaryAddress) == "" && e.Location.Latitude == 0 && e.Location.Longitude == 0 {
+			v.add(fieldPath(locationPath, "address"), errBlankAddress)
+		}
+	}
 	if e.Contact == nil {
-		return errNilEndpointContact
+		v.add(fieldPath(path, "contact"), errNilEndpointContact)
+	} else {
+		e.Contact.validate(v, fieldPath(path, "contact"))
+	}
+	if len([]rune(string(e.SpecialInstructions))) > maxDeliveryFieldLength {
+		v.add(fieldPath(path, "special_instructions"), errTooLongInstructions)
 	}
-	return nil
 }
 
 func (c *Client) RequestDelivery(req *DeliveryRequest) (*Delivery, error) {
+	return c.RequestDeliveryContext(context.Background(), req)
+}
+
+// RequestDeliveryContext is like RequestDelivery but uses ctx
+// to carry deadlines and cancellation for the request.
+func (c *Client) RequestDeliveryContext(ctx context.Context, req *DeliveryRequest) (*Delivery, error) {
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
+	req, err := c.quoteBeforeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
 	blob, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
 	theURL := fmt.Sprintf("%s/deliveries", c.baseURL())
-	httpReq, err := http.NewRequest("POST", theURL, bytes.NewReader(blob))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", theURL, bytes.NewReader(blob))
 	if err != nil {
 		return nil, err
 	}
@@ -232,12 +327,18 @@ var errBlankDeliveryID = errors.New("expecting a non-blank deliveryID")
 // potential cancellation fees associated.
 // See https://developer.uber.com/docs/deliveries/faq for more information.
 func (c *Client) CancelDelivery(deliveryID string) error {
+	return c.CancelDeliveryContext(context.Background(), deliveryID)
+}
+
+// CancelDeliveryContext is like CancelDelivery but uses ctx
+// to carry deadlines and cancellation for the request.
+func (c *Client) CancelDeliveryContext(ctx context.Context, deliveryID string) error {
 	deliveryID = strings.TrimSpace(deliveryID)
 	if deliveryID == "" {
 		return errBlankDeliveryID
 	}
 	theURL := fmt.Sprintf("%s/deliveries/%s/cancel", c.baseURL(), deliveryID)
-	httpReq, err := http.NewRequest("POST", theURL, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", theURL, nil)
 	if err != nil {
 		return err
 	}
@@ -245,13 +346,50 @@ func (c *Client) CancelDelivery(deliveryID string) error {
 	return err
 }
 
+// DeliveryByID retrieves the current state of the delivery referenced by its ID.
+func (c *Client) DeliveryByID(deliveryID string) (*Delivery, error) {
+	return c.DeliveryByIDContext(context.Background(), deliveryID)
+}
+
+// DeliveryByIDContext is like DeliveryByID but uses ctx
+// to carry deadlines and cancellation for the request.
+func (c *Client) DeliveryByIDContext(ctx context.Context, deliveryID string) (*Delivery, error) {
+	deliveryID = strings.TrimSpace(deliveryID)
+	if deliveryID == "" {
+		return nil, errBlankDeliveryID
+	}
+	theURL := fmt.Sprintf("%s/deliveries/%s", c.baseURL(), deliveryID)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", theURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	blob, _, err := c.doHTTPReq(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	delivery := new(Delivery)
+	if err := json.Unmarshal(blob, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
 type DeliveryListRequest struct {
-	Status        Status `json:"status,omitempty"`
-	LimitPerPage  int64  `json:"limit"`
-	MaxPageNumber int64  `json:"max_page,omitempty"`
-	StartOffset   int64  `json:"offset"`
+	// Status if set only lists the deliveries in that status.
+	// A nil *DeliveryListRequest lists the deliveries whose
+	// receipt is ready.
+	Status DeliveryStatus `json:"status,omitempty"`
+
+	LimitPerPage  int64 `json:"limit"`
+	MaxPageNumber int64 `json:"max_page,omitempty"`
+	StartOffset   int64 `json:"offset"`
 
 	ThrottleDurationMs int64 `json:"throttle_duration_ms"`
+
+	// Cursor if set resumes paging from a cursor returned
+	// earlier and takes precedence over StartOffset.
+	// MaxPageNumber then counts from it.
+	Cursor Cursor `json:"cursor,omitempty"`
 }
 
 type DeliveryThread struct {
@@ -263,6 +401,7 @@ type DeliveryPage struct {
 	Err        error       `json:"error"`
 	PageNumber int64       `json:"page_number,omitempty"`
 	Deliveries []*Delivery `json:"deliveries,omitempty"`
+	NextCursor Cursor      `json:"next_cursor,omitempty"`
 }
 
 type recvDelivery struct {
@@ -273,120 +412,126 @@ type recvDelivery struct {
 }
 
 type deliveryPager struct {
-	Offset int64  `json:"offset"`
-	Limit  int64  `json:"limit"`
-	Status Status `json:"status"`
+	Offset int64          `json:"offset"`
+	Limit  int64          `json:"limit"`
+	Status DeliveryStatus `json:"status"`
 }
 
-const (
-	NoThrottle = -1
-
-	defaultThrottleDurationMs = 150 * time.Millisecond
-)
-
 // ListDeliveries requires authorization with OAuth2.0 with
 // the delivery scope set.
 func (c *Client) ListDeliveries(dReq *DeliveryListRequest) (*DeliveryThread, error) {
-	if dReq == nil {
-		dReq = &DeliveryListRequest{Status: StatusReceiptReady}
-	}
+	return c.ListDeliveriesContext(context.Background(), dReq)
+}
 
-	baseURL := c.legacyV1BaseURL()
-	fullURL := fmt.Sprintf("%s/deliveries", baseURL)
-	qv, err := otils.ToURLValues(&deliveryPager{
-		Limit:  dReq.LimitPerPage,
-		Status: dReq.Status,
-		Offset: dReq.StartOffset,
-	})
+// ListDeliveriesContext is like ListDeliveries but paging stops
+// once ctx is done, in addition to when Cancel is invoked.
+func (c *Client) ListDeliveriesContext(ctx context.Context, dReq *DeliveryListRequest) (*DeliveryThread, error) {
+	p, err := c.DeliveriesPaginator(dReq)
 	if err != nil {
 		return nil, err
 	}
+	pagesChan, cancelFn := streamPages(ctx, p, func(page *Page[*Delivery], err error) *DeliveryPage {
+		dp := &DeliveryPage{Err: err, PageNumber: int64(p.pageNumber)}
+		if page != nil {
+			dp.PageNumber = int64(page.PageNumber)
+			dp.Deliveries = page.Items
+			dp.NextCursor = page.NextCursor
+		}
+		return dp
+	})
+	return &DeliveryThread{Cancel: cancelFn, Pages: pagesChan}, nil
+}
 
-	if len(qv) > 0 {
-		fullURL = fmt.Sprintf("%s/deliveries?%s", baseURL, qv.Encode())
+// DeliveriesPaginator returns a Paginator over the deliveries
+// of the user, as configured by dReq which can be nil.
+func (c *Client) DeliveriesPaginator(dReq *DeliveryListRequest) (*Paginator[*Delivery], error) {
+	if dReq == nil {
+		dReq = &DeliveryListRequest{Status: DeliveryStatus(StatusReceiptReady)}
 	}
 
-	parsedURL, err := url.Parse(fullURL)
-	if err != nil {
-		return nil, err
-	}
-	parsedBaseURL, err := url.Parse(baseURL)
-	if err != nil {
-		return nil, err
-	}
+	baseURL := c.legacyV1BaseURL()
+	deliveriesURL := func(query string) (string, error) {
+		fullURL := fmt.Sprintf("%s/deliveries", baseURL)
+		if query != "" {
+			fullURL = fmt.Sprintf("%s/deliveries?%s", baseURL, query)
+		}
 
-	var errsList []string
-	if want, got := parsedBaseURL.Scheme, parsedURL.Scheme; got != want {
-		errsList = append(errsList, fmt.Sprintf("gotScheme=%q wantBaseScheme=%q", got, want))
-	}
-	if want, got := parsedBaseURL.Host, parsedURL.Host; got != want {
-		errsList = append(errsList, fmt.Sprintf("gotHost=%q wantBaseHost=%q", got, want))
+		parsedURL, err := url.Parse(fullURL)
+		if err != nil {
+			return "", err
+		}
+		parsedBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return "", err
+		}
+
+		var errsList []string
+		if want, got := parsedBaseURL.Scheme, parsedURL.Scheme; got != want {
+			errsList = append(errsList, fmt.Sprintf("gotScheme=%q wantBaseScheme=%q", got, want))
+		}
+		if want, got := parsedBaseURL.Host, parsedURL.Host; got != want {
+			errsList = append(errsList, fmt.Sprintf("gotHost=%q wantBaseHost=%q", got, want))
+		}
+		if len(errsList) > 0 {
+			return "", errors.New(strings.Join(errsList, "\n"))
+		}
+		return fullURL, nil
 	}
-	if len(errsList) > 0 {
-		return nil, errors.New(strings.Join(errsList, "\n"))
+
+	firstPageQuery := func(offset int64) (string, error) {
+		qv, err := otils.ToURLValues(&deliveryPager{
+			Limit:  dReq.LimitPerPage,
+			Status: dReq.Status,
+			Offset: offset,
+		})
+		if err != nil {
+			return "", err
+		}
+		return deliveriesURL(qv.Encode())
 	}
 
-	maxPage := dReq.MaxPageNumber
-	pageExceeded := func(pageNumber int64) bool {
-		return maxPage > 0 && pageNumber >= maxPage
+	// Fail early for queries that wouldn't make it to Uber.
+	if _, err := firstPageQuery(dReq.StartOffset); err != nil {
+		return nil, err
 	}
 
-	fullDeliveriesBaseURL := fmt.Sprintf("%s/deliveries", baseURL)
-	resChan := make(chan *DeliveryPage)
-	cancelChan, cancelFn := makeCancelParadigm()
+	fetch := func(ctx context.Context, cursor *pageCursor) (*fetchedPage[*Delivery], error) {
+		var fullURL string
+		var err error
+		if cursor.Token != "" {
+			// Uber hands out the query for the next page.
+			fullURL, err = deliveriesURL(cursor.Token)
+		} else {
+			fullURL, err = firstPageQuery(cursor.Offset)
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	go func() {
-		defer close(resChan)
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		slurp, _, err := c.doReq(req)
+		if err != nil {
+			return nil, err
+		}
 
-		pageNumber := int64(0)
-		throttleDurationMs := defaultThrottleDurationMs
-		if dReq.ThrottleDurationMs == NoThrottle {
-			throttleDurationMs = 0
-		} else {
-			throttleDurationMs = time.Duration(dReq.ThrottleDurationMs) * time.Millisecond
+		recv := new(recvDelivery)
+		if err := json.Unmarshal(slurp, recv); err != nil {
+			return nil, err
 		}
 
-		for {
-			page := &DeliveryPage{PageNumber: pageNumber}
-
-			req, err := http.NewRequest("GET", fullURL, nil)
-			if err != nil {
-				page.Err = err
-				resChan <- page
-				return
-			}
-
-			slurp, _, err := c.doReq(req)
-			if err != nil {
-				page.Err = err
-				resChan <- page
-				return
-			}
-
-			recv := new(recvDelivery)
-			if err := json.Unmarshal(slurp, recv); err != nil {
-				page.Err = err
-				resChan <- page
-				return
-			}
-
-			page.Deliveries = recv.Deliveries
-			resChan <- page
-			pageNumber += 1
-			pageToken := recv.NextPageQuery
-			if pageExceeded(pageNumber) || pageToken == "" || len(recv.Deliveries) == 0 {
-				return
-			}
-
-			fullURL = fmt.Sprintf("%s?%s", fullDeliveriesBaseURL, pageToken)
-
-			select {
-			case <-cancelChan:
-				return
-			case <-time.After(throttleDurationMs):
-			}
+		fp := &fetchedPage[*Delivery]{items: recv.Deliveries, count: recv.Count}
+		if recv.NextPageQuery != "" {
+			fp.next = &pageCursor{Token: recv.NextPageQuery}
 		}
-	}()
+		return fp, nil
+	}
 
-	return &DeliveryThread{Cancel: cancelFn, Pages: resChan}, nil
+	throttle := time.Duration(dReq.ThrottleDurationMs) * time.Millisecond
+	if dReq.ThrottleDurationMs == NoThrottle {
+		throttle = NoThrottle
+	}
+	return newPaginator(listingDeliveries, fetch, dReq.Cursor, dReq.StartOffset, throttle, int(dReq.MaxPageNumber)), nil
 }
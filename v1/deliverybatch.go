@@ -0,0 +1,150 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentDeliveryRequests is the maximum number of deliveries
+// that RequestDeliveries and CancelBatch submit or cancel at once.
+const maxConcurrentDeliveryRequests = 5
+
+// DeliveryBatch is the outcome of RequestDeliveries.
+type DeliveryBatch struct {
+	// Deliveries and Errs are in the order of the requests.
+	// For every request either its delivery or its error is set.
+	Deliveries []*Delivery `json:"deliveries"`
+	Errs       []error     `json:"-"`
+
+	// Batch groups the deliveries that were created. Its ID is the
+	// batch ID that Uber assigned to all of them, blank if Uber did
+	// not assign the same batch ID to every delivery created.
+	Batch *Batch `json:"batch"`
+}
+
+// Err returns the errors of the failed requests
+// joined, or nil if every delivery was created.
+func (db *DeliveryBatch) Err() error {
+	var errs []error
+	for i, err := range db.Errs {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("request #%d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var errNoDeliveryRequests = errors.New("expecting at least one delivery request")
+
+// RequestDeliveries requests a delivery for each of reqs so that
+// they can be batched at pickup. Every request is validated before
+// any of them is submitted and an error is returned if any is invalid.
+//
+// Requests are then submitted concurrently, which means that their
+// PromptOnQuote callbacks can be invoked concurrently too. Failing
+// to create a delivery does not stop the others from being created,
+// and is reported by the Errs of the returned batch instead.
+func (c *Client) RequestDeliveries(ctx context.Context, reqs []*DeliveryRequest) (*DeliveryBatch, error) {
+	if len(reqs) == 0 {
+		return nil, errNoDeliveryRequests
+	}
+	for i, req := range reqs {
+		if err := req.Validate(); err != nil {
+			return nil, fmt.Errorf("request #%d: %w", i, err)
+		}
+	}
+
+	db := &DeliveryBatch{
+		Deliveries: make([]*Delivery, len(reqs)),
+		Errs:       make([]error, len(reqs)),
+	}
+	var wg sync.WaitGroup
+	sem := make(chan bool, maxConcurrentDeliveryRequests)
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req *DeliveryRequest) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+
+			db.Deliveries[i], db.Errs[i] = c.RequestDeliveryContext(ctx, req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	db.Batch = batchOf(db.Deliveries)
+	return db, nil
+}
+
+// batchOf groups the created deliveries. The batch ID is only set
+// if Uber assigned the same batch ID to every one of them.
+func batchOf(deliveries []*Delivery) *Batch {
+	batch := new(Batch)
+	sameBatch := true
+	for _, delivery := range deliveries {
+		if delivery == nil {
+			continue
+		}
+		var batchID string
+		if delivery.Batch != nil {
+			batchID = delivery.Batch.ID
+		}
+		if len(batch.Deliveries) == 0 {
+			batch.ID = batchID
+		} else if batchID != batch.ID {
+			sameBatch = false
+		}
+		batch.Deliveries = append(batch.Deliveries, delivery.ID)
+	}
+	if !sameBatch {
+		batch.ID = ""
+	}
+	batch.Count = int64(len(batch.Deliveries))
+	return batch
+}
+
+var errNilBatch = errors.New("expecting a non-nil batch")
+
+// CancelBatch cancels every delivery of batch with CancelDelivery.
+// All the deliveries are attempted even if some fail to be canceled,
+// and the returned error joins the errors of those that failed.
+func (c *Client) CancelBatch(ctx context.Context, batch *Batch) error {
+	if batch == nil {
+		return errNilBatch
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(batch.Deliveries))
+	sem := make(chan bool, maxConcurrentDeliveryRequests)
+	for i, deliveryID := range batch.Deliveries {
+		wg.Add(1)
+		go func(i int, deliveryID string) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+
+			if err := c.CancelDeliveryContext(ctx, deliveryID); err != nil {
+				errs[i] = fmt.Errorf("delivery %q: %w", strings.TrimSpace(deliveryID), err)
+			}
+		}(i, deliveryID)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/orijtech/otils"
+)
+
+type DeliveryReceipt struct {
+	DeliveryID string `json:"delivery_id"`
+
+	// Charges is the breakdown of TotalFee.
+	Charges []*DeliveryCharge `json:"charges"`
+
+	// ChargeAdjustments are the amounts credited or
+	// debited after the fee was computed, for example
+	// promotions or the cancellation fee.
+	ChargeAdjustments []*DeliveryCharge `json:"charge_adjustments"`
+
+	// TotalFee is the fee of the delivery before adjustments.
+	TotalFee otils.NullableFloat64 `json:"total_fee"`
+
+	// TotalCharged is the amount charged to the payment method.
+	TotalCharged otils.NullableFloat64 `json:"total_charged"`
+
+	// TotalOwed is the amount still owed after attempting
+	// to charge the payment method. It is null if the
+	// delivery was paid in full.
+	TotalOwed otils.NullableFloat64 `json:"total_owed"`
+
+	CurrencyCode CurrencyCode `json:"currency_code"`
+
+	CreatedAt uint64 `json:"created_at"`
+}
+
+type DeliveryCharge struct {
+	Name   string                `json:"name"`
+	Amount otils.NullableFloat64 `json:"amount"`
+}
+
+// TotalFeeMoney returns the fee of the delivery before adjustments.
+func (dr *DeliveryReceipt) TotalFeeMoney() Money {
+	return moneyFromFloat(dr.TotalFee, otils.NullableString(dr.CurrencyCode))
+}
+
+// TotalChargedMoney returns the amount charged to the payment method.
+func (dr *DeliveryReceipt) TotalChargedMoney() Money {
+	return moneyFromFloat(dr.TotalCharged, otils.NullableString(dr.CurrencyCode))
+}
+
+// TotalOwedMoney returns the amount still owed for the delivery.
+func (dr *DeliveryReceipt) TotalOwedMoney() Money {
+	return moneyFromFloat(dr.TotalOwed, otils.NullableString(dr.CurrencyCode))
+}
+
+// DeliveryReceipt retrieves the receipt of the delivery referenced
+// by its ID. Receipts are only available for completed deliveries.
+func (c *Client) DeliveryReceipt(deliveryID string) (*DeliveryReceipt, error) {
+	return c.DeliveryReceiptContext(context.Background(), deliveryID)
+}
+
+// DeliveryReceiptContext is like DeliveryReceipt but uses ctx
+// to carry deadlines and cancellation for the request.
+func (c *Client) DeliveryReceiptContext(ctx context.Context, deliveryID string) (*DeliveryReceipt, error) {
+	deliveryID = strings.TrimSpace(deliveryID)
+	if deliveryID == "" {
+		return nil, errBlankDeliveryID
+	}
+	theURL := fmt.Sprintf("%s/deliveries/%s/receipt", c.baseURL(), deliveryID)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", theURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	blob, _, err := c.doHTTPReq(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	receipt := new(DeliveryReceipt)
+	if err := json.Unmarshal(blob, receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+type DeliveryRatingType string
+
+const (
+	// RatingTypeBinary is a thumbs up, RatingValue 1,
+	// or a thumbs down, RatingValue 0.
+	RatingTypeBinary DeliveryRatingType = "binary"
+)
+
+// DeliveryRating is the feedback about the
+// courier at either the pickup or the dropoff.
+type DeliveryRating struct {
+	// Waypoint is the endpoint being rated
+	// i.e PickupWaypoint or Dropoffpoint.
+	Waypoint WaypointType `json:"waypoint"`
+
+	// RatingType if unset defaults to RatingTypeBinary.
+	RatingType  DeliveryRatingType `json:"rating_type"`
+	RatingValue int                `json:"rating_value"`
+
+	// Tags are optional and describe the
+	// experience e.g "courier_not_on_time".
+	Tags []string `json:"tags,omitempty"`
+
+	// Comments is optional and limited to 2500 characters.
+	Comments string `json:"comments,omitempty"`
+}
+
+const maxDeliveryRatingComments = 2500
+
+var (
+	errNilDeliveryRating   = errors.New("expecting a non-nil rating")
+	errInvalidWaypoint     = errors.New(`expecting the waypoint to be either "pickup" or "dropoff"`)
+	errInvalidRatingValue  = errors.New("expecting a binary rating value of either 0 or 1")
+	errUnknownRatingType   = errors.New("unknown rating type")
+	errTooLongRatingRemark = errors.New("expecting comments of at most 2500 characters")
+)
+
+func (dr *DeliveryRating) Validate() error {
+	if dr == nil {
+		return errNilDeliveryRating
+	}
+	if dr.Waypoint != PickupWaypoint && dr.Waypoint != Dropoffpoint {
+		return errInvalidWaypoint
+	}
+	switch dr.RatingType {
+	case "", RatingTypeBinary:
+		if dr.RatingValue != 0 && dr.RatingValue != 1 {
+			return errInvalidRatingValue
+		}
+	default:
+		return errUnknownRatingType
+	}
+	if len([]rune(dr.Comments)) > maxDeliveryRatingComments {
+		return errTooLongRatingRemark
+	}
+	return nil
+}
+
+// RateDelivery submits feedback about the courier of
+// the delivery referenced by its ID.
+func (c *Client) RateDelivery(deliveryID string, rating *DeliveryRating) error {
+	return c.RateDeliveryContext(context.Background(), deliveryID, rating)
+}
+
+// RateDeliveryContext is like RateDelivery but uses ctx
+// to carry deadlines and cancellation for the request.
+func (c *Client) RateDeliveryContext(ctx context.Context, deliveryID string, rating *DeliveryRating) error {
+	deliveryID = strings.TrimSpace(deliveryID)
+	if deliveryID == "" {
+		return errBlankDeliveryID
+	}
+	if err := rating.Validate(); err != nil {
+		return err
+	}
+
+	// Shallow copy of the original then modify the copy.
+	modRating := new(DeliveryRating)
+	*modRating = *rating
+	if modRating.RatingType == "" {
+		modRating.RatingType = RatingTypeBinary
+	}
+	blob, err := json.Marshal(modRating)
+	if err != nil {
+		return err
+	}
+	theURL := fmt.Sprintf("%s/deliveries/%s/rating", c.baseURL(), deliveryID)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", theURL, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	_, _, err = c.doHTTPReq(httpReq)
+	return err
+}
@@ -15,6 +15,7 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -35,7 +36,13 @@ const (
 const driverV1API = "v1"
 
 func (c *Client) DriverProfile() (*Profile, error) {
-	return c.retrieveProfile("/partners/me", driverV1API)
+	return c.DriverProfileContext(context.Background())
+}
+
+// DriverProfileContext is like DriverProfile but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) DriverProfileContext(ctx context.Context) (*Profile, error) {
+	return c.retrieveProfile(ctx, "/partners/me", driverV1API)
 }
 
 type PaymentCategory string
@@ -48,12 +55,6 @@ const (
 	CategoryOther          PaymentCategory = "other"
 )
 
-const (
-	defaultDriverPaymentsLimitPerPage = 50
-
-	defaultThrottleDuration = 150 * time.Millisecond
-)
-
 type DriverInfoResponse struct {
 	Cancel func()
 	Pages  <-chan *DriverInfoPage
@@ -69,7 +70,8 @@ type driverInfoWrap struct {
 
 func (dpq *DriverInfoQuery) toRealDriverQuery() *realDriverQuery {
 	rdpq := &realDriverQuery{
-		Offset: dpq.Offset,
+		Offset:       dpq.Offset,
+		LimitPerPage: dpq.LimitPerPage,
 	}
 	if dpq.StartDate != nil {
 		rdpq.StartTimeUnix = dpq.StartDate.Unix()
@@ -105,6 +107,11 @@ type DriverInfoQuery struct {
 	MaxPageNumber int `json:"max_page_number,omitempty"`
 
 	Throttle time.Duration `json:"throttle,omitempty"`
+
+	// Cursor if set resumes paging from a cursor returned
+	// earlier and takes precedence over Offset.
+	// MaxPageNumber then counts from it.
+	Cursor Cursor `json:"cursor,omitempty"`
 }
 
 type DriverInfoPage struct {
@@ -112,10 +119,38 @@ type DriverInfoPage struct {
 	Payments   []*Payment `json:"payments,omitempty"`
 	Trips      []*Trip    `json:"trips,omitempty"`
 	Err        error      `json:"error"`
+	NextCursor Cursor     `json:"next_cursor,omitempty"`
 }
 
 func (c *Client) ListDriverTrips(dpq *DriverInfoQuery) (*DriverInfoResponse, error) {
-	return c.listDriverInfo(dpq, "/partners/trips")
+	return c.ListDriverTripsContext(context.Background(), dpq)
+}
+
+// ListDriverTripsContext is like ListDriverTrips but paging stops
+// once ctx is done, in addition to when Cancel is invoked.
+func (c *Client) ListDriverTripsContext(ctx context.Context, dpq *DriverInfoQuery) (*DriverInfoResponse, error) {
+	p, err := c.DriverTripsPaginator(dpq)
+	if err != nil {
+		return nil, err
+	}
+	pagesChan, cancelFn := streamPages(ctx, p, func(page *Page[*Trip], err error) *DriverInfoPage {
+		dip := &DriverInfoPage{Err: err, PageNumber: p.pageNumber}
+		if page != nil {
+			dip.PageNumber = page.PageNumber
+			dip.Trips = page.Items
+			dip.NextCursor = page.NextCursor
+		}
+		return dip
+	})
+	return &DriverInfoResponse{Cancel: cancelFn, Pages: pagesChan}, nil
+}
+
+// DriverTripsPaginator returns a Paginator over the trips
+// of the driver, as configured by dpq which can be nil.
+func (c *Client) DriverTripsPaginator(dpq *DriverInfoQuery) (*Paginator[*Trip], error) {
+	return newDriverInfoPaginator(c, dpq, listingDriverTrips, "/partners/trips", func(recv *driverInfoWrap) []*Trip {
+		return recv.Trips
+	}), nil
 }
 
 // DriverPayments returns the payments for the given driver.
@@ -128,105 +163,77 @@ func (c *Client) ListDriverTrips(dpq *DriverInfoQuery) (*DriverInfoResponse, err
 // array. Drivers working for fleet managers will receive payments from the fleet
 // manager and not from Uber.
 func (c *Client) ListDriverPayments(dpq *DriverInfoQuery) (*DriverInfoResponse, error) {
-	return c.listDriverInfo(dpq, "/partners/payments")
+	return c.ListDriverPaymentsContext(context.Background(), dpq)
 }
 
-func (c *Client) listDriverInfo(dpq *DriverInfoQuery, path string) (*DriverInfoResponse, error) {
-	if dpq == nil {
-		dpq = new(DriverInfoQuery)
+// ListDriverPaymentsContext is like ListDriverPayments but paging
+// stops once ctx is done, in addition to when Cancel is invoked.
+func (c *Client) ListDriverPaymentsContext(ctx context.Context, dpq *DriverInfoQuery) (*DriverInfoResponse, error) {
+	p, err := c.DriverPaymentsPaginator(dpq)
+	if err != nil {
+		return nil, err
 	}
+	pagesChan, cancelFn := streamPages(ctx, p, func(page *Page[*Payment], err error) *DriverInfoPage {
+		dip := &DriverInfoPage{Err: err, PageNumber: p.pageNumber}
+		if page != nil {
+			dip.PageNumber = page.PageNumber
+			dip.Payments = page.Items
+			dip.NextCursor = page.NextCursor
+		}
+		return dip
+	})
+	return &DriverInfoResponse{Cancel: cancelFn, Pages: pagesChan}, nil
+}
 
-	throttleDuration := dpq.Throttle
-	if throttleDuration == NoThrottle {
-		throttleDuration = 0
-	} else if throttleDuration <= 0 {
-		throttleDuration = defaultThrottleDuration
-	}
+// DriverPaymentsPaginator returns a Paginator over the payments
+// of the driver, as configured by dpq which can be nil.
+func (c *Client) DriverPaymentsPaginator(dpq *DriverInfoQuery) (*Paginator[*Payment], error) {
+	return newDriverInfoPaginator(c, dpq, listingDriverPayments, "/partners/payments", func(recv *driverInfoWrap) []*Payment {
+		return recv.Payments
+	}), nil
+}
 
-	maxPageNumber := dpq.MaxPageNumber
-	pageExceeds := func(pageNumber int) bool {
-		return maxPageNumber > 0 && pageNumber >= maxPageNumber
+func newDriverInfoPaginator[T any](c *Client, dpq *DriverInfoQuery, kind, path string, pick func(*driverInfoWrap) []T) *Paginator[T] {
+	if dpq == nil {
+		dpq = new(DriverInfoQuery)
 	}
 
 	baseURL := fmt.Sprintf("%s%s", c.baseURL(driverV1API), path)
 	rdpq := dpq.toRealDriverQuery()
-	limitPerPage := rdpq.LimitPerPage
-	if limitPerPage <= 0 {
-		limitPerPage = defaultDriverPaymentsLimitPerPage
-	}
 
-	cancelChan, cancelFn := makeCancelParadigm()
-	resChan := make(chan *DriverInfoPage)
-	go func() {
-		defer close(resChan)
-
-		pageNumber := 0
-
-		for {
-			curPage := new(DriverInfoPage)
-			curPage.PageNumber = pageNumber
-
-			qv, err := otils.ToURLValues(rdpq)
-			if err != nil {
-				curPage.Err = err
-				resChan <- curPage
-				return
-			}
-
-			fullURL := baseURL
-			if len(qv) > 0 {
-				fullURL += "?" + qv.Encode()
-			}
-
-			req, err := http.NewRequest("GET", fullURL, nil)
-			if err != nil {
-				curPage.Err = err
-				resChan <- curPage
-				return
-			}
-			blob, _, err := c.doAuthAndHTTPReq(req)
-			if err != nil {
-				curPage.Err = err
-				resChan <- curPage
-				return
-			}
-
-			recv := new(driverInfoWrap)
-			if err := json.Unmarshal(blob, recv); err != nil {
-				curPage.Err = err
-				resChan <- curPage
-				return
-			}
-
-			// No payments nor trips sent back, so a sign that we are at the end
-			if len(recv.Payments) == 0 && len(recv.Trips) == 0 {
-				return
-			}
-
-			curPage.Trips = recv.Trips
-			curPage.Payments = recv.Payments
-
-			resChan <- curPage
-
-			pageNumber += 1
-			if pageExceeds(pageNumber) {
-				return
-			}
-
-			select {
-			case <-cancelChan:
-				return
-			case <-time.After(throttleDuration):
-			}
-
-			rdpq.Offset += recv.Limit
+	fetch := func(ctx context.Context, cursor *pageCursor) (*fetchedPage[T], error) {
+		query := *rdpq
+		query.Offset = int(cursor.Offset)
+		qv, err := otils.ToURLValues(&query)
+		if err != nil {
+			return nil, err
+		}
+
+		fullURL := baseURL
+		if len(qv) > 0 {
+			fullURL += "?" + qv.Encode()
 		}
-	}()
 
-	resp := &DriverInfoResponse{
-		Cancel: cancelFn,
-		Pages:  resChan,
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		blob, _, err := c.doAuthAndHTTPReq(req)
+		if err != nil {
+			return nil, err
+		}
+
+		recv := new(driverInfoWrap)
+		if err := json.Unmarshal(blob, recv); err != nil {
+			return nil, err
+		}
+		items := pick(recv)
+		return &fetchedPage[T]{
+			items: items,
+			count: int64(recv.Count),
+			next:  nextOffsetCursor(cursor, len(items), int64(recv.Count)),
+		}, nil
 	}
 
-	return resp, nil
+	return newPaginator(kind, fetch, dpq.Cursor, int64(rdpq.Offset), dpq.Throttle, dpq.MaxPageNumber)
 }
@@ -48,6 +48,30 @@ func (ae *ActionableError) Action() string {
 	return ae.action
 }
 
+// Retryable reports whether the request that produced
+// the error can be safely retried as is.
+func (ae *ActionableError) Retryable() bool {
+	return ae != nil && ae.retryable
+}
+
+// StatusCode returns the HTTP status code that
+// Uber responds with for this kind of error.
+func (ae *ActionableError) StatusCode() int {
+	if ae == nil {
+		return 0
+	}
+	return ae.code
+}
+
+// Signature returns the code that Uber uses to
+// identify this kind of error e.g "surge".
+func (ae *ActionableError) Signature() string {
+	if ae == nil {
+		return ""
+	}
+	return ae.signature
+}
+
 var (
 	ErrProccessingRequest = errors.New("error_")
 )
@@ -317,6 +341,30 @@ var ErrInternalServerError = &ActionableError{
 	signature: "internal_server_error",
 }
 
+// * 404 :: no_current_trip :: The user is not currently on a trip.
+var ErrNoCurrentTrip = &ActionableError{
+	msg:       "the user is not currently on a trip",
+	code:      404,
+	signature: "no_current_trip",
+}
+
+// * 409 :: cancellation_fee :: Canceling the trip at this point incurs a
+//				 cancellation fee. Confirm with the rider
+//				 before canceling again.
+var ErrCancellationFee = &ActionableError{
+	msg:       "canceling this trip incurs a cancellation fee",
+	code:      409,
+	signature: "cancellation_fee",
+}
+
+// * 409 :: trip_already_completed :: The trip was already completed
+//				       and can no longer be canceled nor updated.
+var ErrTripAlreadyCompleted = &ActionableError{
+	msg:       "the trip was already completed",
+	code:      409,
+	signature: "trip_already_completed",
+}
+
 var actionableErrorsIndex map[string]*ActionableError
 
 var actionableErrsList = [...]*ActionableError{
@@ -352,6 +400,9 @@ var actionableErrsList = [...]*ActionableError{
 	29: ErrInvalidSeatCount,
 	30: ErrDestinationOutsideServiceArea,
 	31: ErrInternalServerError,
+	32: ErrNoCurrentTrip,
+	33: ErrCancellationFee,
+	34: ErrTripAlreadyCompleted,
 }
 
 func init() {
@@ -359,7 +410,7 @@ func init() {
 	for i, ae := range actionableErrsList {
 		_, previouslyIn := actionableErrorsIndex[ae.signature]
 		if previouslyIn {
-			log.Fatalf("actionableError: %#d signature (%q) already exists", i, ae.signature)
+			log.Fatalf("actionableError: #%d signature (%q) already exists", i, ae.signature)
 		}
 		actionableErrorsIndex[ae.signature] = ae
 	}
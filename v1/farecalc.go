@@ -0,0 +1,117 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/orijtech/otils"
+)
+
+var (
+	errNilPriceDetails   = errors.New("expecting non-nil price details")
+	errNegativeDistance  = errors.New("expecting a non-negative distance")
+	errNegativeDuration  = errors.New("expecting a non-negative duration")
+	errInvalidMultiplier = errors.New("expecting a surge multiplier of at least 1")
+)
+
+// Estimate computes offline the breakdown of the fare of a trip
+// covering distance in duration, in the manner of the "fare_breakdown"
+// returned by Uber: the base fare, the distance and time charges, the
+// surge if surgeMultiplier is above 1, a "Minimum Fare" adjustment
+// if the trip would otherwise cost less than pd.Minimum, then each
+// service fee. A surgeMultiplier of 0 means that there is no surge.
+//
+// Amounts are rounded to the minor unit of pd.CurrencyCode and, since
+// the fare is known, the Low and High amounts of each item are equal.
+// Tolls and the other charges only Uber knows about are not included.
+func (pd *PriceDetails) Estimate(distance Distance, duration time.Duration, surgeMultiplier float64) ([]*FareBreakdown, error) {
+	if pd == nil {
+		return nil, errNilPriceDetails
+	}
+	if distance < 0 {
+		return nil, errNegativeDistance
+	}
+	if duration < 0 {
+		return nil, errNegativeDuration
+	}
+	if surgeMultiplier == 0 {
+		surgeMultiplier = 1
+	}
+	if surgeMultiplier < 1 {
+		return nil, errInvalidMultiplier
+	}
+	perUnit, err := unitOfDistance(string(pd.DistanceUnit))
+	if err != nil {
+		return nil, err
+	}
+
+	places := pd.CurrencyCode.minorUnits()
+	amountOf := func(f float64) Decimal { return DecimalFromFloat(f).Round(places) }
+
+	base := amountOf(float64(pd.Base))
+	distanceCharge := DecimalFromFloat(float64(distance / perUnit)).Mul(DecimalFromFloat(float64(pd.CostPerDistanceUnit))).Round(places)
+	timeCharge := DecimalFromFloat(duration.Minutes()).Mul(DecimalFromFloat(float64(pd.CostPerMinute))).Round(places)
+
+	breakdown := []*FareBreakdown{
+		fareItem("Base Fare", base, places),
+		fareItem("Distance", distanceCharge, places),
+		fareItem("Time", timeCharge, places),
+	}
+	fare := base.Add(distanceCharge).Add(timeCharge)
+	if surgeMultiplier > 1 {
+		surge := fare.Mul(DecimalFromFloat(surgeMultiplier - 1)).Round(places)
+		name := "Surge x" + strconv.FormatFloat(surgeMultiplier, 'f', -1, 64)
+		breakdown = append(breakdown, fareItem(name, surge, places))
+		fare = fare.Add(surge)
+	}
+	if minimum := amountOf(float64(pd.Minimum)); fare.Cmp(minimum) < 0 {
+		breakdown = append(breakdown, fareItem("Minimum Fare", minimum.Sub(fare), places))
+	}
+	for _, fee := range pd.ServiceFees {
+		if fee == nil {
+			continue
+		}
+		item := fareItem(fee.Name, amountOf(float64(fee.Fee)), places)
+		item.ServiceFee = item.Low
+		breakdown = append(breakdown, item)
+	}
+	return breakdown, nil
+}
+
+// EstimateTotal is like Estimate but only returns the total fare.
+func (pd *PriceDetails) EstimateTotal(distance Distance, duration time.Duration, surgeMultiplier float64) (Money, error) {
+	breakdown, err := pd.Estimate(distance, duration, surgeMultiplier)
+	if err != nil {
+		return Money{}, err
+	}
+	var total Decimal
+	for _, item := range breakdown {
+		total = total.Add(DecimalFromFloat(float64(item.Low)))
+	}
+	return NewMoney(total.Round(pd.CurrencyCode.minorUnits()), pd.CurrencyCode), nil
+}
+
+func fareItem(name string, amount Decimal, places int) *FareBreakdown {
+	value := otils.NullableFloat64(amount.Float64())
+	return &FareBreakdown{
+		Low:           value,
+		High:          value,
+		DisplayAmount: otils.NullableString(amount.StringFixed(places)),
+		DisplayName:   otils.NullableString(name),
+	}
+}
@@ -15,6 +15,7 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -28,8 +29,9 @@ type Trip struct {
 	// it only return "completed" for now.
 	Status Status `json:"status,omitempty"`
 
-	// Length of activity in miles.
-	DistanceMiles float64 `json:"distance,omitempty"`
+	// Length of activity in miles. It is converted from
+	// Distance and Unit when trips are decoded.
+	DistanceMiles float64 `json:"-"`
 
 	// UnixTimestamp of activity start time.
 	StartTimeUnix int64 `json:"start_time,omitempty"`
@@ -45,8 +47,10 @@ type Trip struct {
 	TripID    string `json:"trip_id,omitempty"`
 	DriverID  string `json:"driver_id,omitempty"`
 
+	// Unit is the unit of Distance, miles if blank.
 	Unit string `json:"distance_unit,omitempty"`
 
+	// Duration is in seconds, see Elapsed.
 	Duration         otils.NullableFloat64 `json:"duration,omitempty"`
 	DurationEstimate otils.NullableFloat64 `json:"duration_estimate,omitempty"`
 
@@ -116,12 +120,18 @@ type Pager struct {
 	LimitPerPage     int64         `json:"limit"`
 	MaxPages         int64         `json:"-"`
 	StartOffset      int64         `json:"offset"`
+
+	// Cursor if set resumes paging from a cursor
+	// returned earlier and takes precedence over
+	// StartOffset. MaxPages then counts from it.
+	Cursor Cursor `json:"-"`
 }
 
 type TripThreadPage struct {
 	TripThread
 	Err        error
 	PageNumber uint64
+	NextCursor Cursor
 }
 
 const (
@@ -139,10 +149,48 @@ func (treq *Pager) adjustPageParams() {
 }
 
 func (c *Client) ListAllMyHistory() (thChan chan *TripThreadPage, cancelFn func(), err error) {
-	return c.ListHistory(nil)
+	return c.ListAllMyHistoryContext(context.Background())
+}
+
+// ListAllMyHistoryContext is like ListAllMyHistory but paging stops
+// once ctx is done, in addition to when cancelFn is invoked.
+func (c *Client) ListAllMyHistoryContext(ctx context.Context) (thChan chan *TripThreadPage, cancelFn func(), err error) {
+	return c.ListHistoryContext(ctx, nil)
 }
 
 func (c *Client) ListHistory(threq *Pager) (thChan chan *TripThreadPage, cancelFn func(), err error) {
+	return c.ListHistoryContext(context.Background(), threq)
+}
+
+// ListHistoryContext is like ListHistory but paging stops once
+// ctx is done, in addition to when cancelFn is invoked.
+func (c *Client) ListHistoryContext(ctx context.Context, threq *Pager) (thChan chan *TripThreadPage, cancelFn func(), err error) {
+	limit := DefaultLimitPerPage
+	if threq != nil && threq.LimitPerPage > 0 {
+		limit = threq.LimitPerPage
+	}
+	p, err := c.HistoryPaginator(threq)
+	if err != nil {
+		return nil, nil, err
+	}
+	thChan, cancelFn = streamPages(ctx, p, func(page *Page[*Trip], err error) *TripThreadPage {
+		ttp := &TripThreadPage{Err: err, PageNumber: uint64(p.pageNumber)}
+		if page != nil {
+			ttp.PageNumber = uint64(page.PageNumber)
+			ttp.Trips = page.Items
+			ttp.Count = page.Count
+			ttp.Limit = limit
+			ttp.Offset = page.Offset
+			ttp.NextCursor = page.NextCursor
+		}
+		return ttp
+	})
+	return thChan, cancelFn, nil
+}
+
+// HistoryPaginator returns a Paginator over the trips in the
+// rider's history, as configured by threq which can be nil.
+func (c *Client) HistoryPaginator(threq *Pager) (*Paginator[*Trip], error) {
 	treq := new(Pager)
 	if threq != nil {
 		*treq = *threq
@@ -151,86 +199,34 @@ func (c *Client) ListHistory(threq *Pager) (thChan chan *TripThreadPage, cancelF
 	// Adjust the paging parameters since they'll be heavily used
 	treq.adjustPageParams()
 
-	requestedMaxPage := treq.MaxPages
-	pageNumberExceeds := func(pageNumber uint64) bool {
-		if requestedMaxPage <= 0 {
-			// No page limit requested at all here
-			return false
+	fetch := func(ctx context.Context, cursor *pageCursor) (*fetchedPage[*Trip], error) {
+		query := *treq
+		query.StartOffset = cursor.Offset
+		qv, err := otils.ToURLValues(&query)
+		if err != nil {
+			return nil, err
 		}
 
-		return pageNumber >= uint64(requestedMaxPage)
-	}
+		fullURL := fmt.Sprintf("%s/history?%s", c.baseURL(), qv.Encode())
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		slurp, _, err := c.doReq(req)
+		if err != nil {
+			return nil, err
+		}
 
-	cancelChan, cancelFn := makeCancelParadigm()
-
-	historyChan := make(chan *TripThreadPage)
-	go func() {
-		defer close(historyChan)
-
-		throttleDuration := 150 * time.Millisecond
-		pageNumber := uint64(0)
-
-		canPage := true
-		for canPage {
-			ttp := new(TripThreadPage)
-			ttp.PageNumber = pageNumber
-
-			qv, err := otils.ToURLValues(treq)
-			if err != nil {
-				ttp.Err = err
-				historyChan <- ttp
-				return
-			}
-
-			fullURL := fmt.Sprintf("%s/history?%s", c.baseURL(), qv.Encode())
-			req, err := http.NewRequest("GET", fullURL, nil)
-			if err != nil {
-				ttp.Err = err
-				historyChan <- ttp
-				return
-			}
-
-			slurp, _, err := c.doReq(req)
-			if err != nil {
-				ttp.Err = err
-				historyChan <- ttp
-				return
-			}
-
-			if err := json.Unmarshal(slurp, ttp); err != nil {
-				ttp.Err = err
-				historyChan <- ttp
-				return
-			}
-
-			historyChan <- ttp
-
-			if ttp.Count <= 0 {
-				// No more items to page
-				return
-			}
-
-			select {
-			case <-cancelChan:
-				// The user has canceled the paging
-				canPage = false
-				return
-
-			case <-time.After(throttleDuration):
-				// Do nothing here, the throttle time expired.
-			}
-
-			// Now it is time to adjust the next offset accordingly with the remaining count
-
-			// Increment the page number as well
-			pageNumber += 1
-			if pageNumberExceeds(pageNumber) {
-				return
-			}
-
-			treq.StartOffset += treq.LimitPerPage
+		thread := new(TripThread)
+		if err := json.Unmarshal(slurp, thread); err != nil {
+			return nil, err
 		}
-	}()
+		return &fetchedPage[*Trip]{
+			items: thread.Trips,
+			count: thread.Count,
+			next:  nextOffsetCursor(cursor, len(thread.Trips), thread.Count),
+		}, nil
+	}
 
-	return historyChan, cancelFn, nil
+	return newPaginator(listingHistory, fetch, treq.Cursor, treq.StartOffset, treq.ThrottleDuration, int(treq.MaxPages)), nil
 }
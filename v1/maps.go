@@ -15,6 +15,7 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -35,12 +36,18 @@ var (
 )
 
 func (c *Client) RequestMap(tripID string) (*Map, error) {
+	return c.RequestMapContext(context.Background(), tripID)
+}
+
+// RequestMapContext is like RequestMap but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) RequestMapContext(ctx context.Context, tripID string) (*Map, error) {
 	if tripID == "" {
 		return nil, errEmptyTripID
 	}
 
 	fullURL := fmt.Sprintf("%s/requests/%s/map", c.baseURL(), tripID)
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +71,13 @@ func (c *Client) RequestMap(tripID string) (*Map, error) {
 // OpenMapForTrip is a convenience method that opens the map
 // for a trip or returns an error if it encounters an error.
 func (c *Client) OpenMapForTrip(tripID string) error {
-	uinfo, err := c.RequestMap(tripID)
+	return c.OpenMapForTripContext(context.Background(), tripID)
+}
+
+// OpenMapForTripContext is like OpenMapForTrip but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) OpenMapForTripContext(ctx context.Context, tripID string) error {
+	uinfo, err := c.RequestMapContext(ctx, tripID)
 	if err != nil {
 		return err
 	}
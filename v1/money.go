@@ -0,0 +1,431 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/orijtech/otils"
+)
+
+// Decimal is an exact decimal number. Amounts that Uber reports as
+// JSON numbers are converted from their shortest decimal form, so
+// that adding them up doesn't accumulate floating point errors.
+// The zero value is 0 and Decimals are immutable.
+type Decimal struct {
+	value *big.Rat
+
+	// scale is the number of decimal places of the value.
+	scale int
+}
+
+var (
+	ErrInvalidDecimal   = errors.New("invalid decimal")
+	ErrCurrencyMismatch = errors.New("amounts in different currencies")
+)
+
+// ParseDecimal parses a decimal number such as "-12.50" or "1.5e3".
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	value, ok := new(big.Rat).SetString(s)
+	if !ok || strings.Contains(s, "/") {
+		return Decimal{}, ErrInvalidDecimal
+	}
+
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		exponent, _ = strconv.Atoi(s[i+1:])
+	}
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+	}
+	return Decimal{value: value, scale: max(scale-exponent, 0)}, nil
+}
+
+// DecimalFromFloat returns the Decimal of the
+// shortest decimal representation of f.
+func DecimalFromFloat(f float64) Decimal {
+	d, _ := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+func DecimalFromInt(i int64) Decimal {
+	return Decimal{value: new(big.Rat).SetInt64(i)}
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.value == nil {
+		return new(big.Rat)
+	}
+	return d.value
+}
+
+func (d Decimal) Add(e Decimal) Decimal {
+	return Decimal{value: new(big.Rat).Add(d.rat(), e.rat()), scale: max(d.scale, e.scale)}
+}
+
+func (d Decimal) Sub(e Decimal) Decimal {
+	return Decimal{value: new(big.Rat).Sub(d.rat(), e.rat()), scale: max(d.scale, e.scale)}
+}
+
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{value: new(big.Rat).Mul(d.rat(), e.rat()), scale: d.scale + e.scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Rat).Neg(d.rat()), scale: d.scale}
+}
+
+// Cmp returns -1, 0 or +1 if d is less than,
+// equal to or greater than e, respectively.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.rat().Cmp(e.rat())
+}
+
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Float64 returns the nearest float64 value of d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.rat().Float64()
+	return f
+}
+
+// String renders d with all of its decimal places.
+func (d Decimal) String() string {
+	return d.rat().FloatString(d.scale)
+}
+
+// StringFixed renders d with at least the given number of decimal
+// places, without ever rounding it i.e "1.005" stays "1.005".
+func (d Decimal) StringFixed(places int) string {
+	return d.rat().FloatString(max(d.scale, places))
+}
+
+// Round returns d rounded to the given number of decimal
+// places, with halves rounded away from zero.
+func (d Decimal) Round(places int) Decimal {
+	rounded, _ := ParseDecimal(d.rat().FloatString(max(places, 0)))
+	return rounded
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers, strings holding
+// a number, and null which is decoded as 0.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Money is an exact amount of money in a currency.
+type Money struct {
+	Amount       Decimal      `json:"amount"`
+	CurrencyCode CurrencyCode `json:"currency_code"`
+}
+
+func NewMoney(amount Decimal, currencyCode CurrencyCode) Money {
+	return Money{Amount: amount, CurrencyCode: CurrencyCode(strings.ToUpper(string(currencyCode)))}
+}
+
+func moneyFromFloat(f otils.NullableFloat64, currencyCode otils.NullableString) Money {
+	return NewMoney(DecimalFromFloat(float64(f)), CurrencyCode(currencyCode))
+}
+
+// ParseMoney parses an amount as displayed by Uber, for example
+// "$12.78" or "CA$1,200", ignoring currency symbols. It is like
+// ParseMoneyLocale with the separators of English.
+func ParseMoney(display string, currencyCode CurrencyCode) (Money, error) {
+	return ParseMoneyLocale(display, currencyCode, "en")
+}
+
+// ParseMoneyLocale parses an amount formatted as customary in locale,
+// as rendered by Money.Format, for example "1.234,50 €" for "de". The
+// amount must use the decimal and grouping separators of the locale,
+// with digits grouped by three, otherwise an error is returned since
+// for example "1.234" is ambiguous. Currency symbols are ignored.
+func ParseMoneyLocale(display string, currencyCode CurrencyCode, locale string) (Money, error) {
+	invalid := fmt.Errorf("%q: %w", display, ErrInvalidDecimal)
+	first := strings.IndexFunc(display, isDigit)
+	last := strings.LastIndexFunc(display, isDigit)
+	if first < 0 {
+		return Money{}, invalid
+	}
+	// Only the sign and the currency symbol surround the digits.
+	prefix, number := display[:first], display[first:last+1]
+	if strings.Contains(display[last+1:], "-") || strings.Count(prefix, "-") > 1 {
+		return Money{}, invalid
+	}
+
+	conv := conventionsFor(locale)
+	integral, fractional, hasDecimal := strings.Cut(number, conv.decimal)
+	if hasDecimal && (fractional == "" || strings.IndexFunc(fractional, isNotDigit) >= 0) {
+		return Money{}, invalid
+	}
+	groups := strings.Split(integral, conv.group)
+	for i, group := range groups {
+		if group == "" || strings.IndexFunc(group, isNotDigit) >= 0 {
+			return Money{}, invalid
+		}
+		if (i > 0 && len(group) != 3) || len(group) > 3 {
+			return Money{}, invalid
+		}
+	}
+
+	digits := strings.Join(groups, "")
+	if hasDecimal {
+		digits += "." + fractional
+	}
+	if strings.Contains(prefix, "-") {
+		digits = "-" + digits
+	}
+	amount, err := ParseDecimal(digits)
+	if err != nil {
+		return Money{}, invalid
+	}
+	return NewMoney(amount, currencyCode), nil
+}
+
+func isDigit(r rune) bool    { return r >= '0' && r <= '9' }
+func isNotDigit(r rune) bool { return !isDigit(r) }
+
+func (m Money) sameCurrency(n Money) error {
+	if !strings.EqualFold(string(m.CurrencyCode), string(n.CurrencyCode)) {
+		return fmt.Errorf("%w: %q and %q", ErrCurrencyMismatch, m.CurrencyCode, n.CurrencyCode)
+	}
+	return nil
+}
+
+func (m Money) Add(n Money) (Money, error) {
+	if err := m.sameCurrency(n); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Add(n.Amount), CurrencyCode: m.CurrencyCode}, nil
+}
+
+func (m Money) Sub(n Money) (Money, error) {
+	if err := m.sameCurrency(n); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Sub(n.Amount), CurrencyCode: m.CurrencyCode}, nil
+}
+
+// Cmp compares the amounts of m and n, see Decimal.Cmp.
+// Amounts in different currencies cannot be compared.
+func (m Money) Cmp(n Money) (int, error) {
+	if err := m.sameCurrency(n); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(n.Amount), nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// String renders m as for example "12.50 USD".
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Amount.StringFixed(m.CurrencyCode.minorUnits()), m.CurrencyCode)
+}
+
+// Format renders m as customary in locale, a BCP 47 language
+// tag such as "en-US", "fr" or "de-DE", for example "$1,234.50"
+// or "1.234,50 €". Unknown locales are formatted like "en".
+func (m Money) Format(locale string) string {
+	conv := conventionsFor(locale)
+	amount := m.Amount.Round(m.CurrencyCode.minorUnits())
+	negative := amount.Sign() < 0
+	if negative {
+		amount = amount.Neg()
+	}
+
+	str := amount.StringFixed(m.CurrencyCode.minorUnits())
+	integral, fractional, _ := strings.Cut(str, ".")
+	var b strings.Builder
+	for i, r := range integral {
+		if i > 0 && (len(integral)-i)%3 == 0 {
+			b.WriteString(conv.group)
+		}
+		b.WriteRune(r)
+	}
+	if fractional != "" {
+		b.WriteString(conv.decimal)
+		b.WriteString(fractional)
+	}
+
+	symbol := m.CurrencyCode.symbol()
+	var formatted string
+	if conv.symbolAfter {
+		formatted = b.String() + "\u00a0" + symbol
+	} else {
+		formatted = symbol + b.String()
+	}
+	if negative {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// numberConventions are the separators of a locale. Symbols placed
+// after amounts, and French thousands, are separated by no-break spaces.
+type numberConventions struct {
+	decimal     string
+	group       string
+	symbolAfter bool
+}
+
+var (
+	englishConventions = &numberConventions{decimal: ".", group: ","}
+	frenchConventions  = &numberConventions{decimal: ",", group: "\u202f", symbolAfter: true}
+	germanConventions  = &numberConventions{decimal: ",", group: ".", symbolAfter: true}
+)
+
+var languageConventions = map[string]*numberConventions{
+	"en":    englishConventions,
+	"ja":    englishConventions,
+	"zh":    englishConventions,
+	"fr":    frenchConventions,
+	"de":    germanConventions,
+	"es":    germanConventions,
+	"it":    germanConventions,
+	"nl":    {decimal: ",", group: "."},
+	"pt":    germanConventions,
+	"pt-br": {decimal: ",", group: "."},
+	"de-ch": {decimal: ".", group: "'"},
+}
+
+func conventionsFor(locale string) *numberConventions {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if conv, ok := languageConventions[locale]; ok {
+		return conv
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	if conv, ok := languageConventions[language]; ok {
+		return conv
+	}
+	return englishConventions
+}
+
+// minorUnits returns the number of decimal places of
+// the currency as per ISO 4217, which is usually 2.
+func (cc CurrencyCode) minorUnits() int {
+	switch strings.ToUpper(string(cc)) {
+	case "BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG",
+		"RWF", "UGX", "UYI", "VND", "VUV", "XAF", "XOF", "XPF":
+		return 0
+	case "BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND":
+		return 3
+	default:
+		return 2
+	}
+}
+
+var currencySymbols = map[string]string{
+	"AUD": "A$",
+	"BRL": "R$",
+	"CAD": "CA$",
+	"CNY": "CN¥",
+	"EUR": "€",
+	"GBP": "£",
+	"INR": "₹",
+	"JPY": "¥",
+	"KRW": "₩",
+	"MXN": "MX$",
+	"NZD": "NZ$",
+	"USD": "$",
+}
+
+func (cc CurrencyCode) symbol() string {
+	code := strings.ToUpper(string(cc))
+	if symbol, ok := currencySymbols[code]; ok {
+		return symbol
+	}
+	return code
+}
+
+// Money returns the value of the upfront fare.
+func (f *Fare) Money() Money {
+	return moneyFromFloat(f.Value, f.CurrencyCode)
+}
+
+// LowMoney returns the lower bound of the estimated price.
+func (pe *PriceEstimate) LowMoney() Money {
+	return moneyFromFloat(pe.LowEstimate, pe.CurrencyCode)
+}
+
+// HighMoney returns the upper bound of the estimated price.
+func (pe *PriceEstimate) HighMoney() Money {
+	return moneyFromFloat(pe.HighEstimate, pe.CurrencyCode)
+}
+
+// MinimumMoney returns the minimum price for the product.
+func (pe *PriceEstimate) MinimumMoney() Money {
+	return moneyFromFloat(pe.MinimumPrice, pe.CurrencyCode)
+}
+
+// AmountMoney returns the net payout to the driver.
+func (p *Payment) AmountMoney() Money {
+	return moneyFromFloat(p.Amount, p.CurrencyCode)
+}
+
+// CashCollectedMoney returns the amount collected in cash by the driver.
+func (p *Payment) CashCollectedMoney() Money {
+	return moneyFromFloat(p.CashCollected, p.CurrencyCode)
+}
+
+// SubtotalMoney parses the subtotal of the receipt.
+func (r *Receipt) SubtotalMoney() (Money, error) {
+	return ParseMoney(string(r.Subtotal), CurrencyCode(r.CurrencyCode))
+}
+
+// TotalFareMoney parses the total fare of the receipt.
+func (r *Receipt) TotalFareMoney() (Money, error) {
+	return ParseMoney(string(r.TotalFare), CurrencyCode(r.CurrencyCode))
+}
+
+// TotalChargedMoney parses the amount charged to the rider.
+func (r *Receipt) TotalChargedMoney() (Money, error) {
+	return ParseMoney(string(r.TotalCharged), CurrencyCode(r.CurrencyCode))
+}
+
+// TotalOwedMoney returns the amount still owed by the rider.
+func (r *Receipt) TotalOwedMoney() Money {
+	return moneyFromFloat(r.TotalOwed, r.CurrencyCode)
+}
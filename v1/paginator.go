@@ -0,0 +1,314 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"iter"
+	"time"
+)
+
+const (
+	// NoThrottle disables the throttling between the
+	// retrieval of two consecutive pages.
+	NoThrottle = -1
+
+	defaultThrottleDuration = 150 * time.Millisecond
+)
+
+var (
+	// ErrNoMorePages is returned by Paginator.Next once all the pages were retrieved.
+	ErrNoMorePages = errors.New("no more pages")
+
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrCursorMismatch = errors.New("cursor was issued by a different listing")
+)
+
+// The kinds of listings, recorded in cursors so that a
+// cursor cannot be used to resume a different listing.
+const (
+	listingHistory        = "history"
+	listingDriverTrips    = "driver_trips"
+	listingDriverPayments = "driver_payments"
+	listingDeliveries     = "deliveries"
+	listingPriceEstimates = "price_estimates"
+	listingTimeEstimates  = "time_estimates"
+)
+
+// Cursor is an opaque and serializable position in a listing. Setting
+// it in a listing query, for example Pager.Cursor, resumes paging from
+// that position, possibly in a different process. The blank Cursor of
+// a page means that there are no pages after it.
+type Cursor string
+
+type cursorPayload struct {
+	Kind   string `json:"k"`
+	Offset int64  `json:"o,omitempty"`
+	Token  string `json:"t,omitempty"`
+}
+
+func encodeCursor(kind string, cursor *pageCursor) Cursor {
+	if cursor == nil {
+		return ""
+	}
+	blob, _ := json.Marshal(&cursorPayload{Kind: kind, Offset: cursor.Offset, Token: cursor.Token})
+	return Cursor(base64.RawURLEncoding.EncodeToString(blob))
+}
+
+func (c Cursor) decode(kind string) (*pageCursor, error) {
+	blob, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	payload := new(cursorPayload)
+	if err := json.Unmarshal(blob, payload); err != nil || payload.Offset < 0 {
+		return nil, ErrInvalidCursor
+	}
+	if payload.Kind != kind {
+		return nil, ErrCursorMismatch
+	}
+	return &pageCursor{Offset: payload.Offset, Token: payload.Token}, nil
+}
+
+// Page is a page of items retrieved by a Paginator.
+type Page[T any] struct {
+	// PageNumber is the 0-based number of the page.
+	PageNumber int `json:"page_number"`
+
+	// Offset is the offset of the first item of the page.
+	Offset int64 `json:"offset"`
+
+	// Count is the total number of items as reported
+	// by Uber, or 0 if the endpoint doesn't report it.
+	Count int64 `json:"count,omitempty"`
+
+	Items []T `json:"items"`
+
+	// NextCursor is the cursor from which to resume paging after
+	// this page, including past the maximum number of pages. It
+	// is blank if this is the last page.
+	NextCursor Cursor `json:"next_cursor,omitempty"`
+}
+
+// pageCursor is the position from which a page is retrieved.
+type pageCursor struct {
+	Offset int64 `json:"offset"`
+
+	// Token is the opaque query that some endpoints
+	// hand out to retrieve the next page.
+	Token string `json:"token,omitempty"`
+}
+
+// fetchedPage is what endpoints return to a Paginator.
+type fetchedPage[T any] struct {
+	items []T
+	count int64
+
+	// next is the cursor of the next page or nil if this
+	// is the last page. Its offset is set by the Paginator.
+	next *pageCursor
+}
+
+type pageFetcher[T any] func(ctx context.Context, cursor *pageCursor) (*fetchedPage[T], error)
+
+// Paginator retrieves the pages of a listing endpoint, one at a time
+// and on demand. All listing endpoints share the same semantics:
+//   - Consecutive pages are retrieved at least a throttle duration
+//     apart, that defaults to 150ms and that NoThrottle disables.
+//   - Paging stops after the maximum number of pages if set, once
+//     Uber returns an empty page, or on the first error. Only the first
+//     page is returned when empty, to report an empty listing.
+//   - Offsets advance by the number of items actually retrieved.
+//
+// A Paginator is not safe for concurrent use.
+type Paginator[T any] struct {
+	kind     string
+	fetch    pageFetcher[T]
+	throttle time.Duration
+	maxPages int
+
+	cursor      *pageCursor
+	pageNumber  int
+	lastFetchAt time.Time
+	err         error
+}
+
+// newPaginator returns a Paginator that starts at resume if set,
+// otherwise at offset. An invalid resume cursor is reported by Next.
+func newPaginator[T any](kind string, fetch pageFetcher[T], resume Cursor, offset int64, throttle time.Duration, maxPages int) *Paginator[T] {
+	p := &Paginator[T]{
+		kind:     kind,
+		fetch:    fetch,
+		cursor:   &pageCursor{Offset: offset},
+		throttle: throttleOrDefault(throttle),
+		maxPages: maxPages,
+	}
+	if resume != "" {
+		p.cursor, p.err = resume.decode(kind)
+	}
+	return p
+}
+
+func throttleOrDefault(throttle time.Duration) time.Duration {
+	switch {
+	case throttle == NoThrottle:
+		return 0
+	case throttle <= 0:
+		return defaultThrottleDuration
+	default:
+		return throttle
+	}
+}
+
+// Next retrieves the next page. It returns ErrNoMorePages once
+// paging is over. Any other error ends paging and is returned
+// by all the subsequent invocations.
+func (p *Paginator[T]) Next(ctx context.Context) (*Page[T], error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.cursor == nil || (p.maxPages > 0 && p.pageNumber >= p.maxPages) {
+		return nil, ErrNoMorePages
+	}
+
+	if !p.lastFetchAt.IsZero() && p.throttle > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Until(p.lastFetchAt.Add(p.throttle))):
+		}
+	}
+
+	cursor := p.cursor
+	p.lastFetchAt = time.Now()
+	fetched, err := p.fetch(ctx, cursor)
+	if err != nil {
+		if ctx.Err() == nil {
+			// Only make errors sticky if they weren't caused by
+			// the caller so that paging can later be resumed.
+			p.err = err
+		}
+		return nil, err
+	}
+
+	if len(fetched.items) == 0 {
+		fetched.next = nil
+		if p.pageNumber > 0 {
+			p.cursor = nil
+			return nil, ErrNoMorePages
+		}
+	}
+
+	page := &Page[T]{
+		PageNumber: p.pageNumber,
+		Offset:     cursor.Offset,
+		Count:      fetched.count,
+		Items:      fetched.items,
+	}
+	p.pageNumber += 1
+	p.cursor = fetched.next
+	if p.cursor != nil {
+		p.cursor.Offset = cursor.Offset + int64(len(fetched.items))
+	}
+	page.NextCursor = encodeCursor(p.kind, p.cursor)
+	return page, nil
+}
+
+// Cursor returns the cursor from which to resume paging, or
+// a blank cursor if all the pages were retrieved.
+func (p *Paginator[T]) Cursor() Cursor {
+	return encodeCursor(p.kind, p.cursor)
+}
+
+// position returns the number and offset of the next page.
+func (p *Paginator[T]) position() (pageNumber int, offset int64) {
+	if p.cursor != nil {
+		offset = p.cursor.Offset
+	}
+	return p.pageNumber, offset
+}
+
+// Pages returns an iterator over the remaining pages. Iteration
+// stops after the first error, which is yielded with a nil page.
+func (p *Paginator[T]) Pages(ctx context.Context) iter.Seq2[*Page[T], error] {
+	return func(yield func(*Page[T], error) bool) {
+		for {
+			page, err := p.Next(ctx)
+			if errors.Is(err, ErrNoMorePages) {
+				return
+			}
+			if !yield(page, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the items of the remaining pages.
+// Iteration stops after the first error, which is yielded with
+// the zero value of T.
+func (p *Paginator[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range p.Pages(ctx) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// streamPages sends the pages of p, converted by makePage, on the
+// returned channel until paging is over or cancelFn is invoked.
+// It backs the channel based listing methods.
+func streamPages[T, P any](ctx context.Context, p *Paginator[T], makePage func(*Page[T], error) P) (chan P, func()) {
+	ctx, cancelFn := context.WithCancel(ctx)
+	pagesChan := make(chan P)
+	go func() {
+		defer close(pagesChan)
+		defer cancelFn()
+
+		for page, err := range p.Pages(ctx) {
+			if err != nil && ctx.Err() != nil {
+				// Paging was canceled, there is no one to report to.
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case pagesChan <- makePage(page, err):
+			}
+		}
+	}()
+	return pagesChan, cancelFn
+}
+
+// nextOffsetCursor returns the cursor of the page that follows the
+// n items retrieved at cursor, out of count, or nil if there is none.
+func nextOffsetCursor(cursor *pageCursor, n int, count int64) *pageCursor {
+	if count <= 0 || cursor.Offset+int64(n) >= count {
+		return nil
+	}
+	return new(pageCursor)
+}
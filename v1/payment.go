@@ -15,6 +15,7 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -174,8 +175,14 @@ type PaymentListing struct {
 }
 
 func (c *Client) ListPaymentMethods() (*PaymentListing, error) {
+	return c.ListPaymentMethodsContext(context.Background())
+}
+
+// ListPaymentMethodsContext is like ListPaymentMethods but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) ListPaymentMethodsContext(ctx context.Context) (*PaymentListing, error) {
 	fullURL := fmt.Sprintf("%s/payment-methods", c.baseURL())
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,7 @@ package uber
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,8 +31,14 @@ const (
 )
 
 func (c *Client) Place(placeName PlaceName) (*Place, error) {
+	return c.PlaceContext(context.Background(), placeName)
+}
+
+// PlaceContext is like Place but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) PlaceContext(ctx context.Context, placeName PlaceName) (*Place, error) {
 	fullURL := fmt.Sprintf("%s/places/%s", c.baseURL(), placeName)
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +83,12 @@ func (pp *PlaceParams) Validate() error {
 
 // UpdatePlace udpates your place's address.
 func (c *Client) UpdatePlace(pp *PlaceParams) (*Place, error) {
+	return c.UpdatePlaceContext(context.Background(), pp)
+}
+
+// UpdatePlaceContext is like UpdatePlace but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) UpdatePlaceContext(ctx context.Context, pp *PlaceParams) (*Place, error) {
 	if err := pp.Validate(); err != nil {
 		return nil, err
 	}
@@ -86,7 +99,7 @@ func (c *Client) UpdatePlace(pp *PlaceParams) (*Place, error) {
 	}
 
 	fullURL := fmt.Sprintf("%s/places/%s", c.baseURL(), pp.Place)
-	req, err := http.NewRequest("PUT", fullURL, bytes.NewReader(blob))
+	req, err := http.NewRequestWithContext(ctx, "PUT", fullURL, bytes.NewReader(blob))
 	if err != nil {
 		return nil, err
 	}
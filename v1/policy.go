@@ -0,0 +1,465 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RidePolicy declares the rules that rides requested by a Client
+// must follow, see Client.SetRidePolicy. Unset rules always pass.
+type RidePolicy struct {
+	// MaxFares is the maximum upfront fare per currency code.
+	// If set, fares in the other currencies are not allowed.
+	MaxFares map[CurrencyCode]Decimal `json:"max_fares,omitempty"`
+
+	// MaxSurgeMultiplier is the maximum surge multiplier allowed.
+	MaxSurgeMultiplier float64 `json:"max_surge_multiplier,omitempty"`
+
+	AllowedProductGroups []ProductGroup `json:"allowed_product_groups,omitempty"`
+
+	// RequireExpenseCode requires rides to set ExpenseCode.
+	RequireExpenseCode bool `json:"require_expense_code,omitempty"`
+
+	// ExpenseCodes if set are the only expense codes allowed.
+	ExpenseCodes []string `json:"expense_codes,omitempty"`
+
+	// AllowedHours are the times of the day at
+	// which rides can be requested, in TimeZone.
+	AllowedHours []*TimeWindow `json:"allowed_hours,omitempty"`
+
+	// TimeZone is the IANA name of the time zone of
+	// AllowedHours e.g "America/New_York", UTC if blank.
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// PickupAreas and DropoffAreas if set are the areas that
+	// pickups and dropoffs must be in. Rides to or from places
+	// such as PlaceHome have no coordinates hence are not allowed.
+	PickupAreas  []*GeoArea `json:"pickup_areas,omitempty"`
+	DropoffAreas []*GeoArea `json:"dropoff_areas,omitempty"`
+}
+
+// TimeWindow is the time of the day between Start and End, both
+// formatted as "15:04". It spans midnight if End is before Start.
+type TimeWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// GeoArea is the disc of radius RadiusMeters
+// around (Latitude, Longitude).
+type GeoArea struct {
+	Name         string  `json:"name,omitempty"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radius_meters"`
+}
+
+type PolicyRule string
+
+const (
+	RuleMaxFare      PolicyRule = "max_fare"
+	RuleMaxSurge     PolicyRule = "max_surge_multiplier"
+	RuleProductGroup PolicyRule = "allowed_product_groups"
+	RuleExpenseCode  PolicyRule = "expense_code"
+	RuleAllowedHours PolicyRule = "allowed_hours"
+	RulePickupArea   PolicyRule = "pickup_areas"
+	RuleDropoffArea  PolicyRule = "dropoff_areas"
+)
+
+var ErrPolicyViolation = errors.New("ride policy violated")
+
+// ErrUnknownFare is returned by RequestRide if the RidePolicy limits
+// fares or surges but the ride was given a FareID that RequestRide did
+// not look up, since the policy cannot be evaluated against that fare.
+// Leave FareID blank for RequestRide to look up the fare, confirming
+// it with PromptOnFare if need be.
+var ErrUnknownFare = errors.New("the fare to evaluate the ride policy against is unknown")
+
+type PolicyViolation struct {
+	Rule   PolicyRule `json:"rule"`
+	Reason string     `json:"reason"`
+}
+
+// PolicyError lists every rule of a RidePolicy that a ride violates.
+// It matches ErrPolicyViolation.
+type PolicyError struct {
+	Violations []*PolicyViolation `json:"violations"`
+}
+
+var _ error = (*PolicyError)(nil)
+
+func (pe *PolicyError) Error() string {
+	reasons := make([]string, 0, len(pe.Violations))
+	for _, v := range pe.Violations {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", v.Rule, v.Reason))
+	}
+	return fmt.Sprintf("%v: %s", ErrPolicyViolation, strings.Join(reasons, "; "))
+}
+
+func (pe *PolicyError) Is(target error) bool { return target == ErrPolicyViolation }
+
+// Violated reports whether rule is among the violations.
+func (pe *PolicyError) Violated(rule PolicyRule) bool {
+	for _, v := range pe.Violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRidePolicy parses a RidePolicy in either JSON or YAML,
+// since YAML documents are converted to JSON before decoding.
+// Unknown fields and invalid rules are reported as errors.
+func ParseRidePolicy(data []byte) (*RidePolicy, error) {
+	blob, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+	rp := new(RidePolicy)
+	if err := dec.Decode(rp); err != nil {
+		return nil, err
+	}
+	if err := rp.Validate(); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// LoadRidePolicy reads and parses the RidePolicy at path.
+func LoadRidePolicy(path string) (*RidePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRidePolicy(data)
+}
+
+var (
+	errNegativeMaxSurge = errors.New("expecting a non-negative max surge multiplier")
+	errNilTimeWindow    = errors.New("expecting a non-nil time window")
+	errNilRideRequest   = errors.New("expecting a non-nil ride request")
+)
+
+// Validate checks that the rules of rp are well formed.
+func (rp *RidePolicy) Validate() error {
+	if rp == nil {
+		return nil
+	}
+	if rp.MaxSurgeMultiplier < 0 {
+		return errNegativeMaxSurge
+	}
+	for code, max := range rp.MaxFares {
+		if max.Sign() < 0 {
+			return fmt.Errorf("max fare for %q: expecting a non-negative amount", code)
+		}
+	}
+	if _, err := rp.location(); err != nil {
+		return err
+	}
+	for i, tw := range rp.AllowedHours {
+		if _, _, err := tw.bounds(); err != nil {
+			return fmt.Errorf("allowed hours #%d: %w", i, err)
+		}
+	}
+	for _, areas := range [][]*GeoArea{rp.PickupAreas, rp.DropoffAreas} {
+		for i, area := range areas {
+			if area == nil || area.RadiusMeters <= 0 {
+				return fmt.Errorf("area #%d: expecting a positive radius", i)
+			}
+		}
+	}
+	return nil
+}
+
+func (rp *RidePolicy) location() (*time.Location, error) {
+	if rp.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(rp.TimeZone)
+}
+
+// bounds returns the minutes since midnight of Start and End.
+func (tw *TimeWindow) bounds() (start, end int, err error) {
+	if tw == nil {
+		return 0, 0, errNilTimeWindow
+	}
+	parse := func(clock string) (int, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(clock))
+		if err != nil {
+			return 0, err
+		}
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	if start, err = parse(tw.Start); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(tw.End); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func (tw *TimeWindow) contains(t time.Time) bool {
+	start, end, err := tw.bounds()
+	if err != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return start <= minute && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// earthRadiusMeters is the mean radius of the Earth.
+const earthRadiusMeters = 6371008.8
+
+// Contains reports whether (lat, lon) is in ga.
+func (ga *GeoArea) Contains(lat, lon float64) bool {
+	return haversineMeters(ga.Latitude, ga.Longitude, lat, lon) <= ga.RadiusMeters
+}
+
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLon := rad(lat2-lat1), rad(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// PolicyInput is what a RidePolicy is evaluated against.
+type PolicyInput struct {
+	Ride *RideRequest `json:"ride"`
+
+	// Fare is the upfront fare of the ride, nil if unknown.
+	Fare *Fare `json:"fare,omitempty"`
+
+	// SurgeMultiplier is 1 or 0 if there is no surge.
+	SurgeMultiplier float64 `json:"surge_multiplier,omitempty"`
+
+	// ProductGroup is the group of the product
+	// requested, blank if unknown.
+	ProductGroup ProductGroup `json:"product_group,omitempty"`
+
+	// At is the time at which the ride is requested.
+	At time.Time `json:"at"`
+}
+
+// Evaluate returns a *PolicyError that lists every rule that in
+// violates, or nil if in complies with rp. A nil rp allows all rides.
+func (rp *RidePolicy) Evaluate(in *PolicyInput) error {
+	if rp == nil {
+		return nil
+	}
+	if in == nil || in.Ride == nil {
+		return errNilRideRequest
+	}
+	if err := rp.Validate(); err != nil {
+		return err
+	}
+
+	var violations []*PolicyViolation
+	for _, v := range []*PolicyViolation{
+		rp.checkFare(in),
+		rp.checkSurge(in.SurgeMultiplier),
+		rp.checkProductGroup(in),
+		rp.checkExpenseCode(in),
+		rp.checkHours(in),
+		checkAreas(RulePickupArea, rp.PickupAreas, in.Ride.StartPlace, in.Ride.StartLatitude, in.Ride.StartLongitude),
+		checkAreas(RuleDropoffArea, rp.DropoffAreas, in.Ride.EndPlace, in.Ride.EndLatitude, in.Ride.EndLongitude),
+	} {
+		if v != nil {
+			violations = append(violations, v)
+		}
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (rp *RidePolicy) checkFare(in *PolicyInput) *PolicyViolation {
+	if len(rp.MaxFares) == 0 {
+		return nil
+	}
+	if in.Fare == nil {
+		return &PolicyViolation{Rule: RuleMaxFare, Reason: "the fare is unknown"}
+	}
+	fare := in.Fare.Money()
+	var max Decimal
+	var ok bool
+	for code, amount := range rp.MaxFares {
+		if strings.EqualFold(string(code), string(fare.CurrencyCode)) {
+			max, ok = amount, true
+			break
+		}
+	}
+	if !ok {
+		return &PolicyViolation{Rule: RuleMaxFare, Reason: fmt.Sprintf("fares in %q are not allowed", fare.CurrencyCode)}
+	}
+	if fare.Amount.Cmp(max) > 0 {
+		return &PolicyViolation{Rule: RuleMaxFare, Reason: fmt.Sprintf("fare of %s exceeds %s", fare, NewMoney(max, fare.CurrencyCode))}
+	}
+	return nil
+}
+
+func (rp *RidePolicy) checkSurge(multiplier float64) *PolicyViolation {
+	if rp == nil || rp.MaxSurgeMultiplier <= 0 || multiplier <= rp.MaxSurgeMultiplier {
+		return nil
+	}
+	return &PolicyViolation{
+		Rule:   RuleMaxSurge,
+		Reason: fmt.Sprintf("surge multiplier of %v exceeds %v", multiplier, rp.MaxSurgeMultiplier),
+	}
+}
+
+func (rp *RidePolicy) checkProductGroup(in *PolicyInput) *PolicyViolation {
+	if len(rp.AllowedProductGroups) == 0 {
+		return nil
+	}
+	for _, group := range rp.AllowedProductGroups {
+		if in.ProductGroup != "" && strings.EqualFold(string(group), string(in.ProductGroup)) {
+			return nil
+		}
+	}
+	if in.ProductGroup == "" {
+		return &PolicyViolation{Rule: RuleProductGroup, Reason: "the product group is unknown"}
+	}
+	return &PolicyViolation{Rule: RuleProductGroup, Reason: fmt.Sprintf("product group %q is not allowed", in.ProductGroup)}
+}
+
+func (rp *RidePolicy) checkExpenseCode(in *PolicyInput) *PolicyViolation {
+	code := strings.TrimSpace(in.Ride.ExpenseCode)
+	if code == "" {
+		if rp.RequireExpenseCode {
+			return &PolicyViolation{Rule: RuleExpenseCode, Reason: "an expense code is required"}
+		}
+		return nil
+	}
+	if len(rp.ExpenseCodes) == 0 {
+		return nil
+	}
+	for _, allowed := range rp.ExpenseCodes {
+		if code == allowed {
+			return nil
+		}
+	}
+	return &PolicyViolation{Rule: RuleExpenseCode, Reason: fmt.Sprintf("expense code %q is not allowed", code)}
+}
+
+func (rp *RidePolicy) checkHours(in *PolicyInput) *PolicyViolation {
+	if len(rp.AllowedHours) == 0 {
+		return nil
+	}
+	loc, _ := rp.location()
+	at := in.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.In(loc)
+	for _, tw := range rp.AllowedHours {
+		if tw.contains(at) {
+			return nil
+		}
+	}
+	return &PolicyViolation{Rule: RuleAllowedHours, Reason: fmt.Sprintf("rides are not allowed at %s", at.Format("15:04 MST"))}
+}
+
+func checkAreas(rule PolicyRule, areas []*GeoArea, place PlaceName, lat, lon float64) *PolicyViolation {
+	if len(areas) == 0 {
+		return nil
+	}
+	if place != "" {
+		return &PolicyViolation{Rule: rule, Reason: fmt.Sprintf("the coordinates of place %q are unknown", place)}
+	}
+	for _, area := range areas {
+		if area.Contains(lat, lon) {
+			return nil
+		}
+	}
+	return &PolicyViolation{Rule: rule, Reason: fmt.Sprintf("(%v, %v) is outside of the allowed areas", lat, lon)}
+}
+
+// SetRidePolicy sets the policy that rides requested by c must
+// follow, or removes it if rp is nil. RequestRide then evaluates
+// it before posting rides and returns a *PolicyError if violated.
+func (c *Client) SetRidePolicy(rp *RidePolicy) {
+	var copied *RidePolicy
+	if rp != nil {
+		copied = new(RidePolicy)
+		*copied = *rp
+	}
+
+	c.Lock()
+	c.ridePolicy = copied
+	c.Unlock()
+}
+
+func (c *Client) ridePolicyOrNil() *RidePolicy {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.ridePolicy
+}
+
+// needsFare reports whether evaluating rp requires the upfront fare.
+func (rp *RidePolicy) needsFare() bool {
+	return rp != nil && (len(rp.MaxFares) > 0 || rp.MaxSurgeMultiplier > 0)
+}
+
+// enforceRidePolicy evaluates the policy of c against rr, with the
+// upfront fare that rr was given by refreshFare, and the product to
+// retrieve its group, if the rules need them.
+func (c *Client) enforceRidePolicy(ctx context.Context, rr *RideRequest) error {
+	rp := c.ridePolicyOrNil()
+	if rp == nil {
+		return nil
+	}
+
+	in := &PolicyInput{Ride: rr, At: time.Now(), SurgeMultiplier: 1}
+	upfrontFare := rr.upfrontFare
+	if upfrontFare == nil && rp.needsFare() {
+		return ErrUnknownFare
+	}
+	if upfrontFare != nil {
+		in.Fare = upfrontFare.Fare
+		if est := upfrontFare.Estimate; est != nil && est.SurgeMultiplier > 0 {
+			in.SurgeMultiplier = float64(est.SurgeMultiplier)
+		}
+	}
+
+	if len(rp.AllowedProductGroups) > 0 && rr.ProductID != "" {
+		product, err := c.ProductByIDContext(ctx, rr.ProductID)
+		if err != nil {
+			return err
+		}
+		in.ProductGroup = product.Group
+	}
+
+	return rp.Evaluate(in)
+}
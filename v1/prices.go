@@ -16,11 +16,11 @@ package uber
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/orijtech/otils"
 )
@@ -42,6 +42,10 @@ type EstimateRequest struct {
 	StartPlace PlaceName `json:"start_place_id"`
 	EndPlace   PlaceName `json:"end_place_id"`
 
+	// MaxProducts if set is the maximum number
+	// of products that CompareProducts quotes.
+	MaxProducts int `json:"-"`
+
 	Pager
 }
 
@@ -98,99 +102,83 @@ type PriceEstimatesPage struct {
 }
 
 func (c *Client) EstimatePrice(ereq *EstimateRequest) (pagesChan chan *PriceEstimatesPage, cancelPaging func(), err error) {
-	if ereq == nil {
-		return nil, nil, errNilEstimateRequest
+	return c.EstimatePriceContext(context.Background(), ereq)
+}
+
+// EstimatePriceContext is like EstimatePrice but paging stops once ctx
+// is done, in addition to when cancelPaging is invoked.
+func (c *Client) EstimatePriceContext(ctx context.Context, ereq *EstimateRequest) (pagesChan chan *PriceEstimatesPage, cancelPaging func(), err error) {
+	p, err := c.PriceEstimatesPaginator(ereq)
+	if err != nil {
+		return nil, nil, err
 	}
+	pagesChan, cancelPaging = streamPages(ctx, p, func(page *Page[*PriceEstimate], err error) *PriceEstimatesPage {
+		ep := &PriceEstimatesPage{Err: err, PageNumber: uint64(p.pageNumber)}
+		if page != nil {
+			ep.PageNumber = uint64(page.PageNumber)
+			ep.Estimates = page.Items
+			ep.Count = page.Count
+		}
+		return ep
+	})
+	return pagesChan, cancelPaging, nil
+}
 
-	pager := new(Pager)
-	if ereq != nil {
-		*pager = ereq.Pager
+// PriceEstimatesPaginator returns a Paginator over the price
+// estimates of the products available for ereq.
+func (c *Client) PriceEstimatesPaginator(ereq *EstimateRequest) (*Paginator[*PriceEstimate], error) {
+	if err := ereq.Validate(); err != nil {
+		return nil, err
 	}
+	return newEstimatesPaginator(c, ereq, listingPriceEstimates, "/estimates/price", func(blob []byte) ([]*PriceEstimate, int64, error) {
+		ep := new(PriceEstimatesPage)
+		if err := json.Unmarshal(blob, ep); err != nil {
+			return nil, 0, err
+		}
+		return ep.Estimates, ep.Count, nil
+	}), nil
+}
 
-	// Adjust the paging parameters since they'll be heavily used
-	pager.adjustPageParams()
+// newEstimatesPaginator returns a Paginator over the estimates
+// at path, as parsed from every page by parse.
+func newEstimatesPaginator[T any](c *Client, ereq *EstimateRequest, kind, path string, parse func([]byte) ([]T, int64, error)) *Paginator[T] {
+	query := new(EstimateRequest)
+	*query = *ereq
 
-	requestedMaxPage := pager.MaxPages
-	pageNumberExceeds := func(pageNumber uint64) bool {
-		if requestedMaxPage <= 0 {
-			// No page limit requested at all here
-			return false
+	// Adjust the paging parameters since they'll be heavily used
+	query.Pager.adjustPageParams()
+	pager := query.Pager
+
+	fetch := func(ctx context.Context, cursor *pageCursor) (*fetchedPage[T], error) {
+		pageQuery := *query
+		pageQuery.StartOffset = cursor.Offset
+		qv, err := otils.ToURLValues(&pageQuery)
+		if err != nil {
+			return nil, err
 		}
 
-		return pageNumber >= uint64(requestedMaxPage)
-	}
+		fullURL := fmt.Sprintf("%s%s?%s", c.baseURL(), path, qv.Encode())
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		slurp, _, err := c.doReq(req)
+		if err != nil {
+			return nil, err
+		}
 
-	cancelChan, cancelFn := makeCancelParadigm()
-	estimatesPageChan := make(chan *PriceEstimatesPage)
-	go func() {
-		defer close(estimatesPageChan)
-
-		throttleDuration := 150 * time.Millisecond
-		pageNumber := uint64(0)
-
-		canPage := true
-		for canPage {
-			ep := new(PriceEstimatesPage)
-			ep.PageNumber = pageNumber
-
-			qv, err := otils.ToURLValues(ereq)
-			if err != nil {
-				ep.Err = err
-				estimatesPageChan <- ep
-				return
-			}
-
-			fullURL := fmt.Sprintf("%s/estimates/price?%s", c.baseURL(), qv.Encode())
-			req, err := http.NewRequest("GET", fullURL, nil)
-			if err != nil {
-				ep.Err = err
-				estimatesPageChan <- ep
-				return
-			}
-
-			slurp, _, err := c.doReq(req)
-			if err != nil {
-				ep.Err = err
-				estimatesPageChan <- ep
-				return
-			}
-
-			if err := json.Unmarshal(slurp, ep); err != nil {
-				ep.Err = err
-				estimatesPageChan <- ep
-				return
-			}
-
-			estimatesPageChan <- ep
-
-			if ep.Count <= 0 {
-				// No more items to page
-				return
-			}
-
-			select {
-			case <-cancelChan:
-				// The user has canceled the paging
-				canPage = false
-				return
-
-			case <-time.After(throttleDuration):
-				// Do nothing here, the throttle time expired.
-			}
-
-			// Now it is time to adjust the next offset accordingly with the remaining count
-
-			// Increment the page number as well
-			pageNumber += 1
-			if pageNumberExceeds(pageNumber) {
-				return
-			}
-
-			pager.StartOffset += pager.LimitPerPage
+		items, count, err := parse(slurp)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		return &fetchedPage[T]{
+			items: items,
+			count: count,
+			next:  nextOffsetCursor(cursor, len(items), count),
+		}, nil
+	}
 
-	return estimatesPageChan, cancelFn, nil
+	return newPaginator(kind, fetch, pager.Cursor, pager.StartOffset, pager.ThrottleDuration, int(pager.MaxPages))
 }
 
 type FareEstimate struct {
@@ -253,15 +241,20 @@ var errInvalidSeatCount = errors.New("invalid seatcount, default and maximum val
 
 const defaultSeatCount = 2
 
-func (esReq *EstimateRequest) validateForUpfrontFare() error {
+// Validate checks every field of esReq and returns a *ValidationError
+// that reports all of the invalid ones by their JSON path.
+func (esReq *EstimateRequest) Validate() error {
 	if esReq == nil {
 		return errNilEstimateRequest
 	}
+	v := new(validator)
+	v.checkTrip(esReq.StartPlace, esReq.StartLatitude, esReq.StartLongitude, esReq.EndPlace, esReq.EndLatitude, esReq.EndLongitude, esReq.SeatCount)
+	return v.err()
+}
 
-	// The number of seats required for uberPool.
-	// Default and maximum value is 2.
-	if esReq.SeatCount < 0 || esReq.SeatCount > 2 {
-		return errInvalidSeatCount
+func (esReq *EstimateRequest) validateForUpfrontFare() error {
+	if err := esReq.Validate(); err != nil {
+		return err
 	}
 
 	if esReq.SeatCount == 0 {
@@ -284,6 +277,12 @@ func (esReq *EstimateRequest) validateForUpfrontFare() error {
 var errNilFare = errors.New("failed to unmarshal the response fare")
 
 func (c *Client) UpfrontFare(esReq *EstimateRequest) (*UpfrontFare, error) {
+	return c.UpfrontFareContext(context.Background(), esReq)
+}
+
+// UpfrontFareContext is like UpfrontFare but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) UpfrontFareContext(ctx context.Context, esReq *EstimateRequest) (*UpfrontFare, error) {
 	if err := esReq.validateForUpfrontFare(); err != nil {
 		return nil, err
 	}
@@ -294,7 +293,7 @@ func (c *Client) UpfrontFare(esReq *EstimateRequest) (*UpfrontFare, error) {
 	}
 
 	fullURL := fmt.Sprintf("%s/requests/estimate", c.baseURL())
-	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewReader(blob))
 	if err != nil {
 		return nil, err
 	}
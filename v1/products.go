@@ -15,6 +15,7 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -54,6 +55,8 @@ type Product struct {
 	DisplayName string `json:"display_name"`
 
 	Description string `json:"description"`
+
+	Group ProductGroup `json:"product_group,omitempty"`
 }
 
 type ProductGroup string
@@ -78,12 +81,18 @@ const (
 // may vary by the time of day due to time restrictions on
 // when that product may be utilized.
 func (c *Client) ListProducts(place *Place) ([]*Product, error) {
+	return c.ListProductsContext(context.Background(), place)
+}
+
+// ListProductsContext is like ListProducts but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) ListProductsContext(ctx context.Context, place *Place) ([]*Product, error) {
 	qv, err := otils.ToURLValues(place)
 	if err != nil {
 		return nil, err
 	}
 	fullURL := fmt.Sprintf("%s/products?%s", c.baseURL(), qv.Encode())
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -106,12 +115,18 @@ var (
 )
 
 func (c *Client) ProductByID(productID string) (*Product, error) {
+	return c.ProductByIDContext(context.Background(), productID)
+}
+
+// ProductByIDContext is like ProductByID but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) ProductByIDContext(ctx context.Context, productID string) (*Product, error) {
 	productID = strings.TrimSpace(productID)
 	if productID == "" {
 		return nil, errEmptyProductID
 	}
 	fullURL := fmt.Sprintf("%s/products/%s", c.baseURL(), productID)
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,7 @@ package uber
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -60,12 +61,18 @@ type Profile struct {
 }
 
 func (c *Client) RetrieveMyProfile() (*Profile, error) {
-	return c.retrieveProfile("/me")
+	return c.RetrieveMyProfileContext(context.Background())
 }
 
-func (c *Client) retrieveProfile(path string, versions ...string) (*Profile, error) {
+// RetrieveMyProfileContext is like RetrieveMyProfile but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) RetrieveMyProfileContext(ctx context.Context) (*Profile, error) {
+	return c.retrieveProfile(ctx, "/me")
+}
+
+func (c *Client) retrieveProfile(ctx context.Context, path string, versions ...string) (*Profile, error) {
 	fullURL := fmt.Sprintf("%s%s", c.baseURL(versions...), path)
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -92,6 +99,12 @@ type PromoCodeRequest struct {
 }
 
 func (c *Client) ApplyPromoCode(promoCode string) (*PromoCode, error) {
+	return c.ApplyPromoCodeContext(context.Background(), promoCode)
+}
+
+// ApplyPromoCodeContext is like ApplyPromoCode but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) ApplyPromoCodeContext(ctx context.Context, promoCode string) (*PromoCode, error) {
 	if promoCode == "" {
 		return nil, errNilPromoCode
 	}
@@ -105,7 +118,7 @@ func (c *Client) ApplyPromoCode(promoCode string) (*PromoCode, error) {
 		return nil, err
 	}
 	fullURL := fmt.Sprintf("%s/me", c.baseURL())
-	req, err := http.NewRequest("PATCH", fullURL, bytes.NewReader(blob))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", fullURL, bytes.NewReader(blob))
 	if err != nil {
 		return nil, err
 	}
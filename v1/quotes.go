@@ -0,0 +1,168 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/orijtech/otils"
+)
+
+// DeliveryQuote is the price that Uber quoted for a delivery.
+// Its ID is used as DeliveryRequest.QuoteID until it expires.
+type DeliveryQuote struct {
+	ID string `json:"quote_id"`
+
+	// EstimatedAt and ExpiresAt are Unix timestamps.
+	EstimatedAt int64 `json:"estimated_at,omitempty"`
+	ExpiresAt   int64 `json:"expires_at,omitempty"`
+
+	Fee          otils.NullableFloat64 `json:"fee"`
+	CurrencyCode CurrencyCode          `json:"currency_code"`
+
+	// PickupETAMinutes and DropoffETAMinutes are the estimated
+	// number of minutes until the pickup and the dropoff.
+	PickupETAMinutes  otils.NullableFloat64 `json:"pickup_eta,omitempty"`
+	DropoffETAMinutes otils.NullableFloat64 `json:"dropoff_eta,omitempty"`
+}
+
+// FeeMoney returns the fee quoted for the delivery.
+func (dq *DeliveryQuote) FeeMoney() Money {
+	return moneyFromFloat(dq.Fee, otils.NullableString(dq.CurrencyCode))
+}
+
+// Expired reports whether the quote can no longer be used.
+func (dq *DeliveryQuote) Expired() bool {
+	return dq.ExpiresAt > 0 && !time.Now().Before(time.Unix(dq.ExpiresAt, 0))
+}
+
+type deliveryQuoteRequest struct {
+	Pickup  *Endpoint `json:"pickup"`
+	Dropoff *Endpoint `json:"dropoff"`
+}
+
+type deliveryQuotesWrap struct {
+	Quotes []*DeliveryQuote `json:"quotes"`
+}
+
+var (
+	errNoDeliveryQuotes  = errors.New("no delivery quotes were returned")
+	errNoUnexpiredQuotes = errors.New("all the delivery quotes have expired")
+	errNilQuoteLocation  = errors.New("the pickup and dropoff locations are required")
+
+	errMixedQuoteCurrencies = errors.New("the delivery quotes are in different currencies")
+)
+
+// RequestDeliveryQuote retrieves quotes for delivering from the pickup
+// to the dropoff of req, of which only the locations are required.
+func (c *Client) RequestDeliveryQuote(req *DeliveryRequest) ([]*DeliveryQuote, error) {
+	return c.RequestDeliveryQuoteContext(context.Background(), req)
+}
+
+// RequestDeliveryQuoteContext is like RequestDeliveryQuote but
+// uses ctx to carry deadlines and cancellation for the request.
+func (c *Client) RequestDeliveryQuoteContext(ctx context.Context, req *DeliveryRequest) ([]*DeliveryQuote, error) {
+	if req == nil || req.Pickup == nil {
+		return nil, errNilPickup
+	}
+	if req.Dropoff == nil {
+		return nil, errNilDropoff
+	}
+	if req.Pickup.Location == nil || req.Dropoff.Location == nil {
+		return nil, errNilQuoteLocation
+	}
+
+	blob, err := json.Marshal(&deliveryQuoteRequest{
+		Pickup:  &Endpoint{Location: req.Pickup.Location},
+		Dropoff: &Endpoint{Location: req.Dropoff.Location},
+	})
+	if err != nil {
+		return nil, err
+	}
+	theURL := fmt.Sprintf("%s/deliveries/quote", c.baseURL())
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", theURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	blob, _, err = c.doHTTPReq(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	wrap := new(deliveryQuotesWrap)
+	if err := json.Unmarshal(blob, wrap); err != nil {
+		return nil, err
+	}
+	if len(wrap.Quotes) == 0 {
+		return nil, errNoDeliveryQuotes
+	}
+	return wrap.Quotes, nil
+}
+
+// cheapestQuote returns the cheapest of the unexpired quotes. Fees in
+// different currencies are not comparable hence an error is returned
+// if the unexpired quotes are not all in the same currency.
+func cheapestQuote(quotes []*DeliveryQuote) (*DeliveryQuote, error) {
+	var cheapest *DeliveryQuote
+	for _, quote := range quotes {
+		if quote == nil || quote.Expired() {
+			continue
+		}
+		if cheapest != nil && quote.CurrencyCode != cheapest.CurrencyCode {
+			return nil, errMixedQuoteCurrencies
+		}
+		if cheapest == nil || quote.Fee < cheapest.Fee {
+			cheapest = quote
+		}
+	}
+	if cheapest == nil {
+		return nil, errNoUnexpiredQuotes
+	}
+	return cheapest, nil
+}
+
+// quoteBeforeRequest returns a copy of dr that uses the quote
+// accepted by PromptOnQuote, if dr has no QuoteID yet.
+func (c *Client) quoteBeforeRequest(ctx context.Context, dr *DeliveryRequest) (*DeliveryRequest, error) {
+	if dr == nil || strings.TrimSpace(dr.QuoteID) != "" || dr.PromptOnQuote == nil {
+		return dr, nil
+	}
+
+	quotes, err := c.RequestDeliveryQuoteContext(ctx, dr)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := cheapestQuote(quotes)
+	if err != nil {
+		return nil, err
+	}
+	if err := dr.PromptOnQuote(quote); err != nil {
+		return nil, err
+	}
+
+	// Shallow copy of the original then modify the copy.
+	modDreq := new(DeliveryRequest)
+	*modDreq = *dr
+	modDreq.QuoteID = quote.ID
+	return modDreq, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is the rate limiting budget of a token
+// as last reported by Uber in the headers:
+//   - X-Rate-Limit-Limit
+//   - X-Rate-Limit-Remaining
+//   - X-Rate-Limit-Reset
+type RateLimit struct {
+	// Limit is the maximum number of requests
+	// allowed within the current window.
+	Limit int `json:"limit"`
+
+	// Remaining is the number of requests
+	// left for the current window.
+	Remaining int `json:"remaining"`
+
+	// Reset is the time at which the current window ends.
+	Reset time.Time `json:"reset"`
+
+	// UpdatedAt is the time at which the
+	// budget was last reported by Uber.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Exhausted reports whether no requests
+// are left until the current window ends.
+func (rl *RateLimit) Exhausted() bool {
+	return rl != nil && rl.Remaining <= 0 && time.Now().Before(rl.Reset)
+}
+
+type RateLimitMode int
+
+const (
+	// RateLimitTrackOnly only records the rate limiting
+	// budget reported by Uber. It is the default mode.
+	RateLimitTrackOnly RateLimitMode = iota
+
+	// RateLimitBlock makes requests wait until the current
+	// window ends, if the budget was used up, before sending.
+	RateLimitBlock
+
+	// RateLimitFailFast makes requests fail with
+	// ErrRateLimitExhausted, if the budget was used up,
+	// instead of sending them.
+	RateLimitFailFast
+)
+
+var ErrRateLimitExhausted = errors.New("rate limit exhausted")
+
+// SetRateLimitMode sets how the client behaves
+// once the rate limiting budget of a token is used up.
+func (c *Client) SetRateLimitMode(mode RateLimitMode) {
+	c.rateLimits.mu.Lock()
+	c.rateLimits.mode = mode
+	c.rateLimits.mu.Unlock()
+}
+
+// RateLimitStatus returns the rate limiting budget of the client's
+// current token as last reported by Uber. It returns nil if no
+// response carrying the rate limiting headers was received yet.
+func (c *Client) RateLimitStatus() *RateLimit {
+	c.RLock()
+	key := c.token
+	c.RUnlock()
+
+	return c.rateLimits.status(key)
+}
+
+type rateLimiter struct {
+	mu     sync.Mutex
+	mode   RateLimitMode
+	limits map[string]*RateLimit
+}
+
+// rateLimitKey returns the token used by req. It is blank for
+// clients whose OAuth2.0 transport sets the token by itself.
+func rateLimitKey(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	auth = strings.TrimPrefix(auth, "Bearer")
+	return strings.TrimSpace(auth)
+}
+
+func (rl *rateLimiter) status(key string) *RateLimit {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	lim, ok := rl.limits[key]
+	if !ok {
+		return nil
+	}
+	copied := new(RateLimit)
+	*copied = *lim
+	return copied
+}
+
+// reserve is invoked before sending a request on behalf
+// of the token identified by key and depending on the mode,
+// it blocks or fails if the token's budget was used up.
+func (rl *rateLimiter) reserve(ctx context.Context, key string) error {
+	for {
+		rl.mu.Lock()
+		mode := rl.mode
+		lim := rl.limits[key]
+		exhausted := lim.Exhausted()
+		var reset time.Time
+		if exhausted {
+			reset = lim.Reset
+		} else if lim != nil && mode != RateLimitTrackOnly {
+			// Optimistically use up some of the budget so that
+			// concurrent requests don't overshoot it.
+			lim.Remaining -= 1
+		}
+		rl.mu.Unlock()
+
+		if !exhausted {
+			return nil
+		}
+
+		switch mode {
+		case RateLimitFailFast:
+			return fmt.Errorf("%w: resets at %s", ErrRateLimitExhausted, reset.Format(time.RFC3339))
+		case RateLimitBlock:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Until(reset)):
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// update records the rate limiting budget from the response headers.
+func (rl *rateLimiter) update(key string, statusCode int, hdr http.Header) {
+	if hdr == nil {
+		return
+	}
+
+	lim := new(RateLimit)
+	limitOK := parseIntHeader(hdr, "X-Rate-Limit-Limit", &lim.Limit)
+	remainingOK := parseIntHeader(hdr, "X-Rate-Limit-Remaining", &lim.Remaining)
+	var resetUnix int
+	if parseIntHeader(hdr, "X-Rate-Limit-Reset", &resetUnix) {
+		lim.Reset = time.Unix(int64(resetUnix), 0)
+	}
+
+	if !limitOK && !remainingOK {
+		if statusCode != http.StatusTooManyRequests {
+			return
+		}
+		// Uber rejected the request without reporting the budget,
+		// so rely on Retry-After to figure out when to try again.
+		retryAfter, ok := parseRetryAfter(hdr)
+		if !ok {
+			return
+		}
+		lim.Remaining = 0
+		lim.Reset = time.Now().Add(retryAfter)
+	}
+	lim.UpdatedAt = time.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.limits == nil {
+		rl.limits = make(map[string]*RateLimit)
+	}
+	if prev, ok := rl.limits[key]; ok && !limitOK {
+		lim.Limit = prev.Limit
+	}
+	rl.limits[key] = lim
+}
+
+func parseIntHeader(hdr http.Header, key string, save *int) bool {
+	value := strings.TrimSpace(hdr.Get(key))
+	if value == "" {
+		return false
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	*save = parsed
+	return true
+}
@@ -15,6 +15,7 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -46,7 +47,7 @@ type Receipt struct {
 
 	// Duration is the ISO 8601 HH:MM:SS
 	// format of the time duration of the trip.
-	Duration otils.NullableString `json:"currency_code"`
+	Duration otils.NullableString `json:"duration"`
 
 	// Distance of the trip charged.
 	Distance otils.NullableString `json:"distance"`
@@ -58,12 +59,18 @@ type Receipt struct {
 var errEmptyReceiptID = errors.New("expecting a non-empty receiptID")
 
 func (c *Client) RequestReceipt(receiptID string) (*Receipt, error) {
+	return c.RequestReceiptContext(context.Background(), receiptID)
+}
+
+// RequestReceiptContext is like RequestReceipt but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) RequestReceiptContext(ctx context.Context, receiptID string) (*Receipt, error) {
 	if receiptID == "" {
 		return nil, errEmptyReceiptID
 	}
 
 	fullURL := fmt.Sprintf("%s/requests/%s/receipt", c.baseURL(), receiptID)
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
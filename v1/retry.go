@@ -0,0 +1,202 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how a Client retries requests
+// that failed with transient errors. A request is retried if:
+//   - Uber responded with an ActionableError that is Retryable
+//     e.g ErrRetryRequest.
+//   - Uber responded with 429 Too Many Requests.
+//   - Uber responded with a 5XX status code or the request
+//     failed with a network error, but only if the request is
+//     idempotent, that is, its method is neither POST nor PATCH.
+//     This ensures that calls such as RequestRide and RequestDelivery
+//     are only retried when Uber tells us that it is safe to.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times that a request
+	// will be sent, including the very first attempt. Values
+	// less than 2 disable retries.
+	MaxAttempts int `json:"max_attempts"`
+
+	// InitialBackoff is the base delay before the first retry.
+	// It doubles on every subsequent retry and full jitter is
+	// applied. If unset, it defaults to DefaultInitialBackoff.
+	InitialBackoff time.Duration `json:"initial_backoff"`
+
+	// MaxBackoff caps the delay between two attempts, including
+	// delays requested by Uber through the Retry-After header.
+	// If unset, it defaults to DefaultMaxBackoff.
+	MaxBackoff time.Duration `json:"max_backoff"`
+}
+
+const (
+	DefaultInitialBackoff = 250 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// SetRetryPolicy makes the client retry transient failures as
+// described by rp. Passing in nil disables retries, which is
+// the default behavior.
+func (c *Client) SetRetryPolicy(rp *RetryPolicy) {
+	var copied *RetryPolicy
+	if rp != nil {
+		copied = new(RetryPolicy)
+		*copied = *rp
+	}
+
+	c.Lock()
+	c.retryPolicy = copied
+	c.Unlock()
+}
+
+func (c *Client) retryPolicyOrNil() *RetryPolicy {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.retryPolicy
+}
+
+func (rp *RetryPolicy) maxAttempts() int {
+	if rp == nil || rp.MaxAttempts < 1 {
+		return 1
+	}
+	return rp.MaxAttempts
+}
+
+func (rp *RetryPolicy) maxBackoff() time.Duration {
+	if rp == nil || rp.MaxBackoff <= 0 {
+		return DefaultMaxBackoff
+	}
+	return rp.MaxBackoff
+}
+
+// backoff returns the delay before the retry that follows
+// the nth attempt. It honors the Retry-After header if set.
+func (rp *RetryPolicy) backoff(attempt int, hdr http.Header) time.Duration {
+	maxBackoff := rp.maxBackoff()
+	if retryAfter, ok := parseRetryAfter(hdr); ok {
+		if retryAfter > maxBackoff {
+			retryAfter = maxBackoff
+		}
+		return retryAfter
+	}
+
+	initialBackoff := rp.InitialBackoff
+	if initialBackoff <= 0 {
+		initialBackoff = DefaultInitialBackoff
+	}
+
+	ceiling := initialBackoff
+	for i := 1; i < attempt && ceiling < maxBackoff; i++ {
+		ceiling *= 2
+	}
+	if ceiling > maxBackoff {
+		ceiling = maxBackoff
+	}
+
+	// Full jitter to ensure that clients that failed
+	// at the same time do not retry at the same time.
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// parseRetryAfter parses the Retry-After header which
+// is either a number of seconds or an HTTP date.
+func parseRetryAfter(hdr http.Header) (time.Duration, bool) {
+	value := hdr.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "POST", "PATCH":
+		return false
+	default:
+		return true
+	}
+}
+
+// shouldRetry reports whether a request that failed with err and
+// the given status code (0 if no response was received) can be retried.
+func shouldRetry(req *http.Request, statusCode int, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimitExhausted) {
+		return false
+	}
+
+	ae := new(ActionableError)
+	if errors.As(err, &ae) && ae.Retryable() {
+		return true
+	}
+	if statusCode == http.StatusTooManyRequests {
+		// The request was rejected without being processed.
+		return true
+	}
+
+	if !isIdempotent(req) {
+		return false
+	}
+	// No response at all means that this was a network error.
+	return statusCode == 0 || statusCode >= 500
+}
+
+var errCannotRewindBody = errors.New("cannot retry a request whose body cannot be rewound")
+
+// rewindRequest returns a copy of req whose body
+// can be sent again for a subsequent attempt.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errCannotRewindBody
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
@@ -16,12 +16,16 @@ package uber
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
+
+	"github.com/orijtech/otils"
 )
 
 type RideRequest struct {
@@ -30,11 +34,31 @@ type RideRequest struct {
 	// set PromptOnFare to review the upfront fare.
 	FareID string `json:"fare_id,omitempty"`
 
+	// FareExpiresAt is the optional Unix timestamp at which the fare
+	// referenced by FareID expires i.e Fare.ExpiresAt. If set and the
+	// fare expired, a new upfront fare is retrieved before requesting
+	// the ride, just like when Uber responds that the fare expired.
+	FareExpiresAt int64 `json:"-"`
+
+	// MaxFareRefreshes is the maximum number of times that a new upfront
+	// fare is retrieved, and PromptOnFare if set is invoked again, because
+	// the fare expired. If unset, it defaults to DefaultMaxFareRefreshes
+	// while negative values disable refreshes.
+	MaxFareRefreshes int `json:"-"`
+
 	// PromptOnFare is an optional callback function that is
 	// used when FareID is blank. It is invoked to inspect and
 	// accept the upfront fare estimate or any surges in effect.
+	// Fare.Money should be used to compare the fare to a budget.
 	PromptOnFare func(*UpfrontFare) error `json:"-"`
 
+	// PromptOnSurge is an optional callback function that is invoked
+	// if Uber responds that surge pricing is in effect. It is passed the
+	// surge details and should return the surge confirmation ID, retrieved
+	// after the rider accepted the surge at SurgeConfirmationURL, so that
+	// the ride can be requested again, or an error to give up on the ride.
+	PromptOnSurge func(*FareEstimate) (confirmationID string, err error) `json:"-"`
+
 	// StartPlace can be used in place of (StartLatitude, StartLongitude)
 	StartPlace PlaceName `json:"start_place_id,omitempty"`
 
@@ -79,15 +103,44 @@ type RideRequest struct {
 	// * Uber For Business: https://www.uber.com/business
 	// * Business Profiles: https://www.uber.com/business/profiles
 	ExpenseMemo string `json:"expense_memo,omitempty"`
+
+	// upfrontFare is the fare last retrieved by refreshFare.
+	upfrontFare *UpfrontFare
+}
+
+const DefaultMaxFareRefreshes = 3
+
+func (rr *RideRequest) maxFareRefreshes() int {
+	switch {
+	case rr.MaxFareRefreshes < 0:
+		return 0
+	case rr.MaxFareRefreshes == 0:
+		return DefaultMaxFareRefreshes
+	default:
+		return rr.MaxFareRefreshes
+	}
+}
+
+func (rr *RideRequest) fareExpired() bool {
+	return rr.FareExpiresAt > 0 && !time.Now().Before(time.Unix(rr.FareExpiresAt, 0))
 }
 
-func (c *Client) preprocessBeforeValidate(rr *RideRequest) (*RideRequest, error) {
-	if rr == nil || strings.TrimSpace(rr.FareID) != "" || rr.PromptOnFare == nil {
+func (c *Client) preprocessBeforeValidate(ctx context.Context, rr *RideRequest) (*RideRequest, error) {
+	if rr == nil || strings.TrimSpace(rr.FareID) != "" {
+		return rr, nil
+	}
+	// A ride policy might be evaluated against the upfront fare.
+	if rr.PromptOnFare == nil && !c.ridePolicyOrNil().needsFare() {
 		return rr, nil
 	}
+	return c.refreshFare(ctx, rr)
+}
 
+// refreshFare retrieves a new upfront fare for rr and returns a copy of
+// rr that uses it, once it has been accepted by PromptOnFare if set.
+func (c *Client) refreshFare(ctx context.Context, rr *RideRequest) (*RideRequest, error) {
 	// Otherwise it is time to get the estimate of the fare
-	upfrontFare, err := c.UpfrontFare(&EstimateRequest{
+	upfrontFare, err := c.UpfrontFareContext(ctx, &EstimateRequest{
 		StartLatitude:  rr.StartLatitude,
 		StartLongitude: rr.StartLongitude,
 		StartPlace:     rr.StartPlace,
@@ -100,26 +153,40 @@ func (c *Client) preprocessBeforeValidate(rr *RideRequest) (*RideRequest, error)
 	if err != nil {
 		return nil, err
 	}
+	if upfrontFare.Fare == nil {
+		return nil, errNilFare
+	}
 
 	modRreq := new(RideRequest)
 	// Shallow copy of the original then modify the copy.
 	*modRreq = *rr
 	modRreq.FareID = string(upfrontFare.Fare.ID)
-	if modRreq.ProductID == "" {
+	modRreq.FareExpiresAt = upfrontFare.Fare.ExpiresAt
+	modRreq.upfrontFare = upfrontFare
+	if modRreq.ProductID == "" && upfrontFare.Trip != nil {
 		modRreq.ProductID = upfrontFare.Trip.ProductID
 	}
 
 	// Otherwise prompt for acceptance
-	if err := rr.PromptOnFare(upfrontFare); err != nil {
-		return nil, err
+	if rr.PromptOnFare != nil {
+		if err := rr.PromptOnFare(upfrontFare); err != nil {
+			return nil, err
+		}
 	}
 
 	return modRreq, nil
 }
 
+// Validate checks every field of rr and returns a *ValidationError
+// that reports all of the invalid ones by their JSON path.
 func (rr *RideRequest) Validate() error {
-	if rr == nil || strings.TrimSpace(rr.FareID) == "" {
-		return ErrInvalidFareID
+	if rr == nil {
+		rr = new(RideRequest)
+	}
+
+	v := new(validator)
+	if strings.TrimSpace(rr.FareID) == "" {
+		v.add("fare_id", ErrInvalidFareID)
 	}
 
 	// Either:
@@ -129,33 +196,96 @@ func (rr *RideRequest) Validate() error {
 	// 2. End:
 	//    * EndPlace
 	//    * (EndLatitude, EndLongitude)
-	if blankPlaceOrCoords(rr.StartPlace, rr.StartLatitude, rr.StartLongitude) {
-		return ErrInvalidStartPlaceOrCoords
+	v.checkTrip(rr.StartPlace, rr.StartLatitude, rr.StartLongitude, rr.EndPlace, rr.EndLatitude, rr.EndLongitude, rr.SeatCount)
+	return v.err()
+}
+
+// RequestRide requests a ride on behalf of the rider. If the fare
+// expired, either before the ride is requested as per FareExpiresAt
+// or as reported by Uber, a new upfront fare is retrieved and the
+// ride is requested again, up to MaxFareRefreshes times after which
+// an error that matches ErrFareExpired is returned.
+//
+// If a RidePolicy was set, the ride is first evaluated against it and
+// a *PolicyError is returned, without requesting the ride, if violated.
+// A policy that limits fares or surges is evaluated against the upfront
+// fare that RequestRide looks up when FareID is blank, and ErrUnknownFare
+// is returned if FareID was set instead.
+func (c *Client) RequestRide(rreq *RideRequest) (*Ride, error) {
+	return c.RequestRideContext(context.Background(), rreq)
+}
+
+// RequestRideContext is like RequestRide but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) RequestRideContext(ctx context.Context, rreq *RideRequest) (*Ride, error) {
+	rr, err := c.preprocessBeforeValidate(ctx, rreq)
+	if err != nil {
+		return nil, err
 	}
 
-	if blankPlaceOrCoords(rr.EndPlace, rr.EndLatitude, rr.EndLongitude) {
-		return ErrInvalidEndPlaceOrCoords
+	if err := rr.Validate(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	for refreshes := 0; ; refreshes++ {
+		var err error = ErrFareExpired
+		if !rr.fareExpired() {
+			if perr := c.enforceRidePolicy(ctx, rr); perr != nil {
+				return nil, perr
+			}
+			ride, rerr := c.requestRideOnce(ctx, rr)
+			if rerr == nil || !errors.Is(rerr, ErrFareExpired) {
+				return ride, rerr
+			}
+			err = rerr
+		}
+
+		if refreshes >= rr.maxFareRefreshes() {
+			return nil, err
+		}
+		if rr, err = c.refreshFare(ctx, rr); err != nil {
+			return nil, err
+		}
+	}
 }
 
-func (c *Client) RequestRide(rreq *RideRequest) (*Ride, error) {
-	rr, err := c.preprocessBeforeValidate(rreq)
-	if err != nil {
+// requestRideOnce requests the ride, confirming
+// the surge with PromptOnSurge if need be.
+func (c *Client) requestRideOnce(ctx context.Context, rr *RideRequest) (*Ride, error) {
+	ride, err := c.postRideRequest(ctx, rr)
+	if err == nil || rr.PromptOnSurge == nil || rr.SurgeConfirmationID != "" {
+		return ride, err
+	}
+	fe := surgeFareEstimate(err)
+	if fe == nil {
 		return nil, err
 	}
+	if v := c.ridePolicyOrNil().checkSurge(float64(fe.SurgeMultiplier)); v != nil {
+		return nil, &PolicyError{Violations: []*PolicyViolation{v}}
+	}
 
-	if err := rr.Validate(); err != nil {
+	confirmationID, perr := rr.PromptOnSurge(fe)
+	if perr != nil {
+		return nil, perr
+	}
+	if strings.TrimSpace(confirmationID) == "" {
 		return nil, err
 	}
 
+	// Shallow copy of the original then modify the copy.
+	surgeRreq := new(RideRequest)
+	*surgeRreq = *rr
+	surgeRreq.SurgeConfirmationID = confirmationID
+	return c.postRideRequest(ctx, surgeRreq)
+}
+
+func (c *Client) postRideRequest(ctx context.Context, rr *RideRequest) (*Ride, error) {
 	blob, err := json.Marshal(rr)
 	if err != nil {
 		return nil, err
 	}
 	fullURL := fmt.Sprintf("%s/requests", c.baseURL())
-	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewReader(blob))
 	if err != nil {
 		return nil, err
 	}
@@ -171,6 +301,42 @@ func (c *Client) RequestRide(rreq *RideRequest) (*Ride, error) {
 	return ride, nil
 }
 
+type surgeConfirmation struct {
+	URL        string                `json:"href"`
+	ID         string                `json:"surge_confirmation_id"`
+	Multiplier otils.NullableFloat64 `json:"multiplier"`
+}
+
+// surgeFareEstimate returns the surge details that Uber
+// sends in the meta of a 409 surge response or nil if err
+// is not such a response.
+func surgeFareEstimate(err error) *FareEstimate {
+	ue := new(Error)
+	if !errors.Is(err, ErrSurge) || !errors.As(err, &ue) || ue.Meta == nil {
+		return nil
+	}
+
+	// Meta was deserialized generically so round trip it
+	// through JSON to retrieve the surge confirmation.
+	blob, merr := json.Marshal(ue.Meta)
+	if merr != nil {
+		return nil
+	}
+	meta := new(struct {
+		SurgeConfirmation *surgeConfirmation `json:"surge_confirmation"`
+	})
+	if err := json.Unmarshal(blob, meta); err != nil || meta.SurgeConfirmation == nil {
+		return nil
+	}
+
+	sc := meta.SurgeConfirmation
+	return &FareEstimate{
+		SurgeConfirmationURL: sc.URL,
+		SurgeConfirmationID:  sc.ID,
+		SurgeMultiplier:      sc.Multiplier,
+	}
+}
+
 var (
 	ErrInvalidStartPlaceOrCoords = errors.New("invalid startPlace or (startLat, startLon)")
 	ErrInvalidEndPlaceOrCoords   = errors.New("invalid endPlace or (endLat, endLon)")
@@ -275,8 +441,14 @@ var errBlankTrip = errors.New("expecting a non-blank trip")
 // used for all Uber riders. See more information about scopes
 // here https://developer.uber.com/docs/riders/guides/scopes.
 func (c *Client) CurrentTrip() (*Trip, error) {
+	return c.CurrentTripContext(context.Background())
+}
+
+// CurrentTripContext is like CurrentTrip but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) CurrentTripContext(ctx context.Context) (*Trip, error) {
 	tripURL := fmt.Sprintf("%s/requests/current", c.baseURL())
-	return c.fetchTripByURL(tripURL)
+	return c.fetchTripByURL(ctx, tripURL)
 }
 
 // TripByID returns the details of a trip whose ID is known.
@@ -284,12 +456,18 @@ func (c *Client) CurrentTrip() (*Trip, error) {
 // used for all Uber riders. See more information about scopes
 // here https://developer.uber.com/docs/riders/guides/scopes.
 func (c *Client) TripByID(id string) (*Trip, error) {
+	return c.TripByIDContext(context.Background(), id)
+}
+
+// TripByIDContext is like TripByID but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) TripByIDContext(ctx context.Context, id string) (*Trip, error) {
 	tripURL := fmt.Sprintf("%s/requests/%s", c.baseURL(), id)
-	return c.fetchTripByURL(tripURL)
+	return c.fetchTripByURL(ctx, tripURL)
 }
 
-func (c *Client) fetchTripByURL(tripURL string) (*Trip, error) {
-	req, err := http.NewRequest("GET", tripURL, nil)
+func (c *Client) fetchTripByURL(ctx context.Context, tripURL string) (*Trip, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", tripURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -307,3 +485,137 @@ func (c *Client) fetchTripByURL(tripURL string) (*Trip, error) {
 	}
 	return tr, nil
 }
+
+var errBlankRequestID = errors.New("expecting a non-blank requestID")
+
+// CancelCurrentTrip cancels the ongoing trip of the authenticated rider.
+// Riders may be charged a fee for canceling a trip, that the API
+// reports as ErrCancellationFee. Trips that already completed cannot be
+// canceled and yield ErrTripAlreadyCompleted, while riders without an
+// ongoing trip get ErrNoCurrentTrip.
+// It is a privileged method that requires the request scope.
+func (c *Client) CancelCurrentTrip() error {
+	return c.CancelCurrentTripContext(context.Background())
+}
+
+// CancelCurrentTripContext is like CancelCurrentTrip but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) CancelCurrentTripContext(ctx context.Context) error {
+	tripURL := fmt.Sprintf("%s/requests/current", c.baseURL())
+	return c.cancelTripByURL(ctx, tripURL)
+}
+
+// CancelRide cancels the ride request referenced by its ID, on
+// behalf of the rider. It reports the same errors as CancelCurrentTrip.
+// It is a privileged method that requires the request scope.
+func (c *Client) CancelRide(requestID string) error {
+	return c.CancelRideContext(context.Background(), requestID)
+}
+
+// CancelRideContext is like CancelRide but uses ctx to
+// carry deadlines and cancellation for the request.
+func (c *Client) CancelRideContext(ctx context.Context, requestID string) error {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		return errBlankRequestID
+	}
+	tripURL := fmt.Sprintf("%s/requests/%s", c.baseURL(), requestID)
+	return c.cancelTripByURL(ctx, tripURL)
+}
+
+func (c *Client) cancelTripByURL(ctx context.Context, tripURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", tripURL, nil)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.doAuthAndHTTPReq(req)
+	return err
+}
+
+// DestinationUpdate is the new destination of an ongoing trip.
+// Either EndPlace or (EndLatitude, EndLongitude) must be set.
+type DestinationUpdate struct {
+	// EndPlace can be used in place of (EndLatitude, EndLongitude)
+	EndPlace PlaceName `json:"end_place_id,omitempty"`
+
+	EndLatitude  float64 `json:"end_latitude,omitempty"`
+	EndLongitude float64 `json:"end_longitude,omitempty"`
+}
+
+func (du *DestinationUpdate) Validate() error {
+	if du == nil {
+		return ErrInvalidEndPlaceOrCoords
+	}
+	if strings.TrimSpace(string(du.EndPlace)) == "" && du.EndLatitude == 0 && du.EndLongitude == 0 {
+		// Nothing to update the destination to.
+		return ErrInvalidEndPlaceOrCoords
+	}
+	if blankPlaceOrCoords(du.EndPlace, du.EndLatitude, du.EndLongitude) {
+		return ErrInvalidEndPlaceOrCoords
+	}
+	return nil
+}
+
+// UpdateCurrentTripDestination changes the destination of the
+// ongoing trip of the authenticated rider and returns the updated trip.
+// It is a privileged method that requires the request scope.
+func (c *Client) UpdateCurrentTripDestination(du *DestinationUpdate) (*Trip, error) {
+	return c.UpdateCurrentTripDestinationContext(context.Background(), du)
+}
+
+// UpdateCurrentTripDestinationContext is like UpdateCurrentTripDestination
+// but uses ctx to carry deadlines and cancellation for the request.
+func (c *Client) UpdateCurrentTripDestinationContext(ctx context.Context, du *DestinationUpdate) (*Trip, error) {
+	tripURL := fmt.Sprintf("%s/requests/current", c.baseURL())
+	return c.updateTripDestinationByURL(ctx, tripURL, du)
+}
+
+// UpdateTripDestination changes the destination of the ongoing
+// trip referenced by its requestID and returns the updated trip.
+// It is a privileged method that requires the request scope.
+func (c *Client) UpdateTripDestination(requestID string, du *DestinationUpdate) (*Trip, error) {
+	return c.UpdateTripDestinationContext(context.Background(), requestID, du)
+}
+
+// UpdateTripDestinationContext is like UpdateTripDestination but
+// uses ctx to carry deadlines and cancellation for the request.
+func (c *Client) UpdateTripDestinationContext(ctx context.Context, requestID string, du *DestinationUpdate) (*Trip, error) {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		return nil, errBlankRequestID
+	}
+	tripURL := fmt.Sprintf("%s/requests/%s", c.baseURL(), requestID)
+	return c.updateTripDestinationByURL(ctx, tripURL, du)
+}
+
+func (c *Client) updateTripDestinationByURL(ctx context.Context, tripURL string, du *DestinationUpdate) (*Trip, error) {
+	if err := du.Validate(); err != nil {
+		return nil, err
+	}
+
+	blob, err := json.Marshal(du)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PATCH", tripURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	blob, _, err = c.doAuthAndHTTPReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Uber responds with 204 No Content on success,
+	// so retrieve the trip to get its latest state.
+	if len(bytes.TrimSpace(blob)) == 0 {
+		return c.fetchTripByURL(ctx, tripURL)
+	}
+
+	tr := new(Trip)
+	if err := json.Unmarshal(blob, tr); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
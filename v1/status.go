@@ -51,3 +51,187 @@ const (
 	// The receipt for the trip is ready.
 	StatusReceiptReady Status = "ready"
 )
+
+// rideTransitions is the graph of the direct transitions
+// between the statuses of a ride request. The usual path is:
+//
+//	processing -> accepted -> arriving -> in_progress -> completed -> ready
+//
+// but the rider can cancel the request until the trip begins, the driver
+// can cancel it once accepted and a driver re-assignment goes back to accepted.
+var rideTransitions = map[Status][]Status{
+	StatusProcessing: {StatusAccepted, StatusNoDriversAvailable, StatusRiderCanceled},
+
+	// Accepted can occur multiple times in case of a driver re-assignment.
+	StatusAccepted: {StatusAccepted, StatusArriving, StatusInProgress, StatusDriverCanceled, StatusRiderCanceled},
+	StatusArriving: {StatusAccepted, StatusInProgress, StatusDriverCanceled, StatusRiderCanceled},
+
+	StatusInProgress: {StatusCompleted},
+	StatusCompleted:  {StatusReceiptReady},
+
+	StatusNoDriversAvailable: nil,
+	StatusDriverCanceled:     nil,
+	StatusRiderCanceled:      nil,
+	StatusReceiptReady:       nil,
+}
+
+// IsTerminal reports whether the ride is over,
+// that is it was completed or it will never happen.
+func (s Status) IsTerminal() bool {
+	switch s {
+	case StatusCompleted, StatusReceiptReady, StatusRiderCanceled, StatusDriverCanceled, StatusNoDriversAvailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsActive reports whether the ride is
+// being matched to a driver or is ongoing.
+func (s Status) IsActive() bool {
+	switch s {
+	case StatusProcessing, StatusAccepted, StatusArriving, StatusInProgress:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsKnown reports whether s is one of the statuses documented by Uber.
+func (s Status) IsKnown() bool {
+	_, known := rideTransitions[s]
+	return known
+}
+
+// CanTransitionTo reports whether a ride in status s can later be in
+// status next. Statuses that are skipped, for example by polling less
+// often than they change, are allowed hence processing can transition
+// to in_progress. Since a driver re-assignment goes back to accepted,
+// accepted and arriving can transition to themselves but no other status can.
+func (s Status) CanTransitionTo(next Status) bool {
+	return reachable(string(s), string(next), func(from string) []string {
+		var succ []string
+		for _, to := range rideTransitions[Status(from)] {
+			succ = append(succ, string(to))
+		}
+		return succ
+	})
+}
+
+type DeliveryStatus string
+
+const (
+	// The delivery request is being matched to a courier.
+	DeliveryStatusProcessing DeliveryStatus = "processing"
+
+	// The delivery request was unfulfilled
+	// because no couriers were available.
+	DeliveryStatusNoCouriersAvailable DeliveryStatus = "no_couriers_available"
+
+	// The courier is "en route" to the pickup location.
+	DeliveryStatusEnRouteToPickup DeliveryStatus = "en_route_to_pickup"
+
+	// The courier has arrived at the pickup location.
+	DeliveryStatusAtPickup DeliveryStatus = "at_pickup"
+
+	// The courier picked up the items and is
+	// "en route" to the dropoff location.
+	DeliveryStatusEnRouteToDropoff DeliveryStatus = "en_route_to_dropoff"
+
+	// The courier has arrived at the dropoff location.
+	DeliveryStatusAtDropoff DeliveryStatus = "at_dropoff"
+
+	// The items were delivered.
+	DeliveryStatusCompleted DeliveryStatus = "completed"
+
+	// The delivery request has been canceled by the client.
+	DeliveryStatusClientCanceled DeliveryStatus = "client_canceled"
+
+	// The courier could not deliver the items.
+	DeliveryStatusUnableToDeliver DeliveryStatus = "unable_to_deliver"
+
+	// The courier is returning the items to the pickup location.
+	DeliveryStatusReturning DeliveryStatus = "returning"
+
+	// The items were returned to the pickup location.
+	DeliveryStatusReturned DeliveryStatus = "returned"
+
+	// The courier could not return the items.
+	DeliveryStatusUnableToReturn DeliveryStatus = "unable_to_return"
+)
+
+// deliveryTransitions is the graph of the direct
+// transitions between the statuses of a delivery. The usual path is:
+//
+//	processing -> en_route_to_pickup -> at_pickup -> en_route_to_dropoff -> at_dropoff -> completed
+//
+// but the client can cancel the delivery until the items are picked up
+// and items that cannot be delivered are returned to the pickup location.
+var deliveryTransitions = map[DeliveryStatus][]DeliveryStatus{
+	DeliveryStatusProcessing:       {DeliveryStatusEnRouteToPickup, DeliveryStatusNoCouriersAvailable, DeliveryStatusClientCanceled},
+	DeliveryStatusEnRouteToPickup:  {DeliveryStatusAtPickup, DeliveryStatusClientCanceled},
+	DeliveryStatusAtPickup:         {DeliveryStatusEnRouteToDropoff, DeliveryStatusClientCanceled},
+	DeliveryStatusEnRouteToDropoff: {DeliveryStatusAtDropoff, DeliveryStatusUnableToDeliver},
+	DeliveryStatusAtDropoff:        {DeliveryStatusCompleted, DeliveryStatusUnableToDeliver},
+	DeliveryStatusUnableToDeliver:  {DeliveryStatusReturning},
+	DeliveryStatusReturning:        {DeliveryStatusReturned, DeliveryStatusUnableToReturn},
+
+	DeliveryStatusNoCouriersAvailable: nil,
+	DeliveryStatusCompleted:           nil,
+	DeliveryStatusClientCanceled:      nil,
+	DeliveryStatusReturned:            nil,
+	DeliveryStatusUnableToReturn:      nil,
+}
+
+// IsTerminal reports whether the delivery is over and
+// that its status will not change any more.
+func (ds DeliveryStatus) IsTerminal() bool {
+	succ, known := deliveryTransitions[ds]
+	return known && len(succ) == 0
+}
+
+// IsActive reports whether the delivery is being
+// matched to a courier or is ongoing.
+func (ds DeliveryStatus) IsActive() bool {
+	succ, known := deliveryTransitions[ds]
+	return known && len(succ) > 0
+}
+
+// IsKnown reports whether ds is one of the statuses documented by Uber.
+func (ds DeliveryStatus) IsKnown() bool {
+	_, known := deliveryTransitions[ds]
+	return known
+}
+
+// CanTransitionTo reports whether a delivery in status ds can later
+// be in status next. As with Status.CanTransitionTo, skipped
+// statuses are allowed. No status can transition to itself.
+func (ds DeliveryStatus) CanTransitionTo(next DeliveryStatus) bool {
+	return reachable(string(ds), string(next), func(from string) []string {
+		var succ []string
+		for _, to := range deliveryTransitions[DeliveryStatus(from)] {
+			succ = append(succ, string(to))
+		}
+		return succ
+	})
+}
+
+// reachable reports whether to can be reached from
+// from by following at least one transition.
+func reachable(from, to string, successors func(string) []string) bool {
+	seen := make(map[string]bool)
+	pending := successors(from)
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+		if cur == to {
+			return true
+		}
+		if seen[cur] {
+			continue
+		}
+		seen[cur] = true
+		pending = append(pending, successors(cur)...)
+	}
+	return false
+}
@@ -15,11 +15,9 @@
 package uber
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"net/http"
-	"time"
 
 	"github.com/orijtech/otils"
 )
@@ -59,97 +57,42 @@ var timeExcludedValues = map[string]bool{
 }
 
 func (c *Client) EstimateTime(treq *EstimateRequest) (pagesChan chan *TimeEstimatesPage, cancelPaging func(), err error) {
-	if treq == nil {
-		return nil, nil, errNilTimeEstimateRequest
-	}
+	return c.EstimateTimeContext(context.Background(), treq)
+}
 
-	pager := new(Pager)
-	if treq != nil {
-		*pager = treq.Pager
+// EstimateTimeContext is like EstimateTime but paging stops once ctx
+// is done, in addition to when cancelPaging is invoked.
+func (c *Client) EstimateTimeContext(ctx context.Context, treq *EstimateRequest) (pagesChan chan *TimeEstimatesPage, cancelPaging func(), err error) {
+	p, err := c.TimeEstimatesPaginator(treq)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	// Adjust the paging parameters since they'll be heavily used
-	pager.adjustPageParams()
-
-	requestedMaxPage := pager.MaxPages
-	pageNumberExceeds := func(pageNumber uint64) bool {
-		if requestedMaxPage <= 0 {
-			// No page limit requested at all here
-			return false
+	pagesChan, cancelPaging = streamPages(ctx, p, func(page *Page[*TimeEstimate], err error) *TimeEstimatesPage {
+		tp := &TimeEstimatesPage{Err: err, PageNumber: uint64(p.pageNumber)}
+		if page != nil {
+			tp.PageNumber = uint64(page.PageNumber)
+			tp.Estimates = page.Items
+			tp.Count = page.Count
 		}
+		return tp
+	})
+	return pagesChan, cancelPaging, nil
+}
 
-		return pageNumber >= uint64(requestedMaxPage)
+// TimeEstimatesPaginator returns a Paginator over the ETAs
+// of the products available for treq.
+func (c *Client) TimeEstimatesPaginator(treq *EstimateRequest) (*Paginator[*TimeEstimate], error) {
+	if treq == nil {
+		return nil, errNilTimeEstimateRequest
 	}
-
-	cancelChan, cancelFn := makeCancelParadigm()
-	estimatesPageChan := make(chan *TimeEstimatesPage)
-	go func() {
-		defer close(estimatesPageChan)
-
-		throttleDuration := 150 * time.Millisecond
-		pageNumber := uint64(0)
-
-		canPage := true
-		for canPage {
-			tp := new(TimeEstimatesPage)
-			tp.PageNumber = pageNumber
-
-			qv, err := otils.ToURLValues(treq)
-			if err != nil {
-				tp.Err = err
-				estimatesPageChan <- tp
-				return
-			}
-
-			fullURL := fmt.Sprintf("%s/estimates/time?%s", c.baseURL(), qv.Encode())
-			req, err := http.NewRequest("GET", fullURL, nil)
-			if err != nil {
-				tp.Err = err
-				estimatesPageChan <- tp
-				return
-			}
-
-			slurp, _, err := c.doReq(req)
-			if err != nil {
-				tp.Err = err
-				estimatesPageChan <- tp
-				return
-			}
-
-			if err := json.Unmarshal(slurp, tp); err != nil {
-				tp.Err = err
-				estimatesPageChan <- tp
-				return
-			}
-
-			estimatesPageChan <- tp
-
-			if tp.Count <= 0 {
-				// No more items to page
-				return
-			}
-
-			select {
-			case <-cancelChan:
-				// The user has canceled the paging
-				canPage = false
-				return
-
-			case <-time.After(throttleDuration):
-				// Do nothing here, the throttle time expired.
-			}
-
-			// Now it is time to adjust the next offset accordingly with the remaining count
-
-			// Increment the page number as well
-			pageNumber += 1
-			if pageNumberExceeds(pageNumber) {
-				return
-			}
-
-			pager.StartOffset += pager.LimitPerPage
+	if err := treq.Validate(); err != nil {
+		return nil, err
+	}
+	return newEstimatesPaginator(c, treq, listingTimeEstimates, "/estimates/time", func(blob []byte) ([]*TimeEstimate, int64, error) {
+		tp := new(TimeEstimatesPage)
+		if err := json.Unmarshal(blob, tp); err != nil {
+			return nil, 0, err
 		}
-	}()
-
-	return estimatesPageChan, cancelFn, nil
+		return tp.Estimates, tp.Count, nil
+	}), nil
 }
@@ -0,0 +1,432 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type RideEventKind string
+
+const (
+	// RideEventStatus is emitted when the status of the trip
+	// changes, including the first time that it is retrieved.
+	RideEventStatus RideEventKind = "status"
+
+	// RideEventLocation is emitted when the
+	// location of the driver's vehicle changes.
+	RideEventLocation RideEventKind = "location"
+
+	// RideEventETA is emitted when the ETA to
+	// either the pickup or the destination changes.
+	RideEventETA RideEventKind = "eta"
+
+	// RideEventDriver is emitted when a driver
+	// is assigned, reassigned or unassigned.
+	RideEventDriver RideEventKind = "driver"
+
+	// RideEventVehicle is emitted when the vehicle changes.
+	RideEventVehicle RideEventKind = "vehicle"
+
+	// RideEventError is emitted when retrieving the trip failed.
+	RideEventError RideEventKind = "error"
+)
+
+type RideEvent struct {
+	Kind RideEventKind `json:"kind"`
+
+	// Trip is the trip as retrieved by the poll that
+	// produced the event. It is nil for RideEventError.
+	Trip *Trip `json:"trip,omitempty"`
+
+	// PreviousStatus is the status of the trip before
+	// this poll. It is blank the first time that the
+	// trip is retrieved.
+	PreviousStatus Status `json:"previous_status,omitempty"`
+
+	Err error `json:"-"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type TrackOptions struct {
+	// PollInterval is the duration to wait between polls.
+	// If unset, it defaults to DefaultTrackPollInterval.
+	PollInterval time.Duration `json:"poll_interval"`
+
+	// StatusPollIntervals if set overrides PollInterval while
+	// the trip is in a given status, for example to poll more
+	// often while the driver is arriving.
+	StatusPollIntervals map[Status]time.Duration `json:"status_poll_intervals,omitempty"`
+
+	// MaxConsecutiveErrors if set stops tracking after as many
+	// polls in a row failed. Otherwise tracking continues until
+	// the trip ends or the context is done.
+	MaxConsecutiveErrors int `json:"max_consecutive_errors,omitempty"`
+}
+
+const DefaultTrackPollInterval = 5 * time.Second
+
+func (topts *TrackOptions) pollInterval(status Status) time.Duration {
+	if topts == nil {
+		return DefaultTrackPollInterval
+	}
+	if interval, ok := topts.StatusPollIntervals[status]; ok && interval > 0 {
+		return interval
+	}
+	if topts.PollInterval > 0 {
+		return topts.PollInterval
+	}
+	return DefaultTrackPollInterval
+}
+
+func (topts *TrackOptions) maxConsecutiveErrors() int {
+	if topts == nil {
+		return 0
+	}
+	return topts.MaxConsecutiveErrors
+}
+
+// TrackRide polls the trip referenced by requestID and emits an event
+// on the returned channel for every change in its status, the location
+// of the vehicle, ETAs and the assigned driver or vehicle. The channel
+// is closed once the trip reaches a terminal status i.e one of:
+//   - completed
+//   - rider_canceled
+//   - driver_canceled
+//   - no_drivers_available
+//
+// or when ctx is done. Failed polls are reported as RideEventError
+// events and polling continues unless opts.MaxConsecutiveErrors is reached.
+// Polls that report a status that cannot follow the previous one, as per
+// Status.CanTransitionTo, are discarded.
+func (c *Client) TrackRide(ctx context.Context, requestID string, opts *TrackOptions) (<-chan *RideEvent, error) {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		return nil, errBlankRequestID
+	}
+
+	eventsChan := make(chan *RideEvent)
+	go func() {
+		defer close(eventsChan)
+
+		emit := func(ev *RideEvent) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case eventsChan <- ev:
+				return true
+			}
+		}
+
+		var prev *Trip
+		consecutiveErrs := 0
+		maxConsecutiveErrs := opts.maxConsecutiveErrors()
+		for {
+			trip, err := c.TripByIDContext(ctx, requestID)
+			now := time.Now()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if !emit(&RideEvent{Kind: RideEventError, Err: err, Timestamp: now}) {
+					return
+				}
+				consecutiveErrs += 1
+				if maxConsecutiveErrs > 0 && consecutiveErrs >= maxConsecutiveErrs {
+					return
+				}
+			} else if prev != nil && !plausibleRideUpdate(prev.Status, trip.Status) {
+				// An out of date response, for example from a lagging
+				// replica, that would otherwise make the ride go back.
+				consecutiveErrs = 0
+			} else {
+				consecutiveErrs = 0
+				for _, kind := range rideChanges(prev, trip) {
+					ev := &RideEvent{Kind: kind, Trip: trip, Timestamp: now}
+					if prev != nil {
+						ev.PreviousStatus = prev.Status
+					}
+					if !emit(ev) {
+						return
+					}
+				}
+				prev = trip
+				if trip.Status.IsTerminal() {
+					return
+				}
+			}
+
+			var status Status
+			if prev != nil {
+				status = prev.Status
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(opts.pollInterval(status)):
+			}
+		}
+	}()
+
+	return eventsChan, nil
+}
+
+// plausibleRideUpdate reports whether a ride in status prev can
+// next be seen in status next. Statuses unknown to this package
+// are let through since Uber could introduce new ones.
+func plausibleRideUpdate(prev, next Status) bool {
+	if prev == next || !prev.IsKnown() || !next.IsKnown() {
+		return true
+	}
+	return prev.CanTransitionTo(next)
+}
+
+// rideChanges returns the kinds of events that
+// describe the changes from prev to cur.
+func rideChanges(prev, cur *Trip) []RideEventKind {
+	if prev == nil {
+		prev = blankTrip
+	}
+
+	var kinds []RideEventKind
+	if prev.Status != cur.Status {
+		kinds = append(kinds, RideEventStatus)
+	}
+	if !sameDriver(prev.Driver, cur.Driver) {
+		kinds = append(kinds, RideEventDriver)
+	}
+	if !sameVehicle(prev.Vehicle, cur.Vehicle) {
+		kinds = append(kinds, RideEventVehicle)
+	}
+	if !sameVehicleLocation(prev.Location, cur.Location) {
+		kinds = append(kinds, RideEventLocation)
+	}
+	if pickupETA(prev) != pickupETA(cur) || destinationETA(prev) != destinationETA(cur) {
+		kinds = append(kinds, RideEventETA)
+	}
+	return kinds
+}
+
+func sameDriver(a, b *Driver) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameVehicle(a, b *Vehicle) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameVehicleLocation(a, b *Location) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Latitude == b.Latitude && a.Longitude == b.Longitude && a.Bearing == b.Bearing
+}
+
+func pickupETA(tr *Trip) int {
+	if tr.Pickup == nil {
+		return 0
+	}
+	return tr.Pickup.ETAMinutes
+}
+
+func destinationETA(tr *Trip) float32 {
+	if tr.Destination == nil {
+		return 0
+	}
+	return tr.Destination.ETAMinutes
+}
+
+type DeliveryEventKind string
+
+const (
+	// DeliveryEventStatus is emitted when the status of the delivery
+	// changes, including the first time that it is retrieved.
+	DeliveryEventStatus DeliveryEventKind = "status"
+
+	// DeliveryEventCourier is emitted when a courier
+	// is assigned, reassigned or unassigned.
+	DeliveryEventCourier DeliveryEventKind = "courier"
+
+	// DeliveryEventLocation is emitted when
+	// the location of the courier changes.
+	DeliveryEventLocation DeliveryEventKind = "location"
+
+	// DeliveryEventETA is emitted when the ETA to
+	// either the pickup or the dropoff changes.
+	DeliveryEventETA DeliveryEventKind = "eta"
+
+	// DeliveryEventError is emitted when retrieving the delivery failed.
+	DeliveryEventError DeliveryEventKind = "error"
+)
+
+type DeliveryEvent struct {
+	Kind DeliveryEventKind `json:"kind"`
+
+	// Delivery is the delivery as retrieved by the poll that
+	// produced the event. It is nil for DeliveryEventError.
+	Delivery *Delivery `json:"delivery,omitempty"`
+
+	// PreviousStatus is the status of the delivery before
+	// this poll. It is blank the first time that the
+	// delivery is retrieved.
+	PreviousStatus DeliveryStatus `json:"previous_status,omitempty"`
+
+	Err error `json:"-"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TrackDelivery polls the delivery referenced by deliveryID and emits an
+// event on the returned channel for every change in its status, courier,
+// the location of the courier and the pickup and dropoff ETAs. The channel
+// is closed once the delivery reaches a terminal status, as reported by
+// DeliveryStatus.IsTerminal, or when ctx is done.
+//
+// opts is used as for TrackRide except for StatusPollIntervals
+// which only applies to rides.
+func (c *Client) TrackDelivery(ctx context.Context, deliveryID string, opts *TrackOptions) (<-chan *DeliveryEvent, error) {
+	deliveryID = strings.TrimSpace(deliveryID)
+	if deliveryID == "" {
+		return nil, errBlankDeliveryID
+	}
+
+	eventsChan := make(chan *DeliveryEvent)
+	go func() {
+		defer close(eventsChan)
+
+		emit := func(ev *DeliveryEvent) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case eventsChan <- ev:
+				return true
+			}
+		}
+
+		var prev *Delivery
+		consecutiveErrs := 0
+		maxConsecutiveErrs := opts.maxConsecutiveErrors()
+		for {
+			delivery, err := c.DeliveryByIDContext(ctx, deliveryID)
+			now := time.Now()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if !emit(&DeliveryEvent{Kind: DeliveryEventError, Err: err, Timestamp: now}) {
+					return
+				}
+				consecutiveErrs += 1
+				if maxConsecutiveErrs > 0 && consecutiveErrs >= maxConsecutiveErrs {
+					return
+				}
+			} else if prev != nil && !plausibleDeliveryUpdate(prev.Status, delivery.Status) {
+				// Discard out of date responses as for rides.
+				consecutiveErrs = 0
+			} else {
+				consecutiveErrs = 0
+				for _, kind := range deliveryChanges(prev, delivery) {
+					ev := &DeliveryEvent{Kind: kind, Delivery: delivery, Timestamp: now}
+					if prev != nil {
+						ev.PreviousStatus = prev.Status
+					}
+					if !emit(ev) {
+						return
+					}
+				}
+				prev = delivery
+				if delivery.Status.IsTerminal() {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(opts.pollInterval("")):
+			}
+		}
+	}()
+
+	return eventsChan, nil
+}
+
+func plausibleDeliveryUpdate(prev, next DeliveryStatus) bool {
+	if prev == next || !prev.IsKnown() || !next.IsKnown() {
+		return true
+	}
+	return prev.CanTransitionTo(next)
+}
+
+// deliveryChanges returns the kinds of events
+// that describe the changes from prev to cur.
+func deliveryChanges(prev, cur *Delivery) []DeliveryEventKind {
+	if prev == nil {
+		prev = new(Delivery)
+	}
+
+	var kinds []DeliveryEventKind
+	if prev.Status != cur.Status {
+		kinds = append(kinds, DeliveryEventStatus)
+	}
+	if !sameCourier(prev.Courier, cur.Courier) {
+		kinds = append(kinds, DeliveryEventCourier)
+	}
+	if !sameVehicleLocation(courierLocation(prev), courierLocation(cur)) {
+		kinds = append(kinds, DeliveryEventLocation)
+	}
+	if endpointETA(prev.Pickup) != endpointETA(cur.Pickup) || endpointETA(prev.Dropoff) != endpointETA(cur.Dropoff) {
+		kinds = append(kinds, DeliveryEventETA)
+	}
+	return kinds
+}
+
+// sameCourier reports whether a and b describe the
+// same courier, regardless of their locations.
+func sameCourier(a, b *Contact) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.FirstName != b.FirstName || a.LastName != b.LastName || a.CompanyName != b.CompanyName || a.Email != b.Email {
+		return false
+	}
+	if a.Phone == nil || b.Phone == nil {
+		return a.Phone == b.Phone
+	}
+	return *a.Phone == *b.Phone
+}
+
+func courierLocation(d *Delivery) *Location {
+	if d.Courier == nil {
+		return nil
+	}
+	return d.Courier.Location
+}
+
+func endpointETA(e *Endpoint) int {
+	if e == nil {
+		return 0
+	}
+	return e.ETAMinutes
+}
@@ -17,24 +17,15 @@ package uber
 import (
 	"encoding/json"
 	"strings"
-	"sync"
 )
 
-func makeCancelParadigm() (<-chan bool, func()) {
-	var cancelOnce sync.Once
-	cancelChan := make(chan bool, 1)
-	cancelFn := func() {
-		cancelOnce.Do(func() {
-			close(cancelChan)
-		})
-	}
-
-	return cancelChan, cancelFn
-}
-
 type Error struct {
-	Meta   interface{}         `json:"meta"`
-	Errors []*statusCodedError `json:"errors"`
+	Meta   interface{}    `json:"meta"`
+	Errors []*StatusError `json:"errors"`
+
+	// StatusCode is the HTTP status code of the response
+	// that the errors were retrieved from.
+	StatusCode int `json:"-"`
 
 	memoized string
 }
@@ -56,11 +47,31 @@ func (ue *Error) Error() string {
 	return ue.memoized
 }
 
+// Unwrap returns each of the errors sent back by Uber. This allows
+// callers to match them against the ActionableError catalogue e.g
+//
+//	if errors.Is(err, uber.ErrSurge) {
+//		// Confirm the surge and then retry.
+//	}
+func (ue *Error) Unwrap() []error {
+	if ue == nil {
+		return nil
+	}
+	errs := make([]error, 0, len(ue.Errors))
+	for _, se := range ue.Errors {
+		if se != nil {
+			errs = append(errs, se)
+		}
+	}
+	return errs
+}
+
 var _ error = (*Error)(nil)
-var _ error = (*statusCodedError)(nil)
+var _ error = (*StatusError)(nil)
 
-type statusCodedError struct {
-	// The json tags are intentionally reversed
+// StatusError is a single error as sent back by Uber.
+type StatusError struct {
+	// The json tags might seem reversed
 	// because an uber status coded error looks
 	// like this:
 	// {
@@ -70,20 +81,70 @@ type statusCodedError struct {
 	// }
 	// of which the above definitions seem reversed compared to
 	// Go's net/http Request where Status is a message and StatusCode is an int.
-	Code    int    `json:"status"`
-	Message string `json:"code"`
-	Title   string `json:"title"`
+	StatusCode int    `json:"status"`
+	Code       string `json:"code"`
+	Title      string `json:"title"`
 
 	memoizedErr string
 }
 
-func (sce *statusCodedError) Error() string {
-	if sce == nil {
+func (se *StatusError) Error() string {
+	if se == nil {
 		return ""
 	}
-	if sce.memoizedErr == "" {
-		blob, _ := json.Marshal(sce)
-		sce.memoizedErr = string(blob)
+	if se.memoizedErr == "" {
+		blob, _ := json.Marshal(se)
+		se.memoizedErr = string(blob)
+	}
+	return se.memoizedErr
+}
+
+// Actionable returns the ActionableError whose signature
+// matches the error's code, or nil if the code is unknown.
+func (se *StatusError) Actionable() *ActionableError {
+	if se == nil {
+		return nil
+	}
+	return lookupErrorBySignature(se.Code)
+}
+
+// Unwrap returns the ActionableError that the
+// error's code resolves to, if any.
+func (se *StatusError) Unwrap() error {
+	if ae := se.Actionable(); ae != nil {
+		return ae
+	}
+	return nil
+}
+
+// legacyError is the error format used by the
+// v1 endpoints, for example by /v1/deliveries.
+type legacyError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// parseError parses the body of an unsuccessful response,
+// returning nil if it isn't a recognizable Uber error.
+func parseError(blob []byte, statusCode int) *Error {
+	ue := new(Error)
+	if err := json.Unmarshal(blob, ue); err != nil {
+		return nil
+	}
+
+	if len(ue.Errors) == 0 {
+		le := new(legacyError)
+		if err := json.Unmarshal(blob, le); err != nil || le.Code == "" {
+			return nil
+		}
+		ue.Errors = append(ue.Errors, &StatusError{Code: le.Code, Title: le.Message})
+	}
+
+	ue.StatusCode = statusCode
+	for _, se := range ue.Errors {
+		if se != nil && se.StatusCode == 0 {
+			se.StatusCode = statusCode
+		}
 	}
-	return sce.memoizedErr
+	return ue
 }
@@ -16,18 +16,23 @@ package uber_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/orijtech/otils"
 	"golang.org/x/oauth2"
 
 	uberOAuth2 "github.com/orijtech/uber/oauth2"
@@ -244,6 +249,342 @@ func TestProductByID(t *testing.T) {
 	}
 }
 
+func TestErrorMapping(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: productByID}
+	client.SetHTTPRoundTripper(backend)
+
+	tests := [...]struct {
+		productID  string
+		want       *uber.ActionableError
+		wantStatus int
+		wantCode   string
+	}{
+		0: {
+			productID: "not-found", want: uber.ErrNoProductFound,
+			wantStatus: http.StatusNotFound, wantCode: "no_product_found",
+		},
+		1: {
+			productID: "surging", want: uber.ErrSurge,
+			wantStatus: http.StatusConflict, wantCode: "surge",
+		},
+		2: {
+			// Unknown codes should still be accessible.
+			productID:  "unknown-code",
+			wantStatus: http.StatusUnprocessableEntity, wantCode: "brand_new_code",
+		},
+		3: {
+			productID: "legacy", want: uber.ErrForbiddenRequest,
+			wantStatus: http.StatusForbidden, wantCode: "forbidden",
+		},
+	}
+
+	for i, tt := range tests {
+		_, err := client.ProductByID(tt.productID)
+		if err == nil {
+			t.Errorf("#%d: expected a non-nil error", i)
+			continue
+		}
+
+		ae := new(uber.ActionableError)
+		gotActionable := errors.As(err, &ae)
+		if tt.want == nil {
+			if gotActionable {
+				t.Errorf("#%d: unexpectedly resolved to %v", i, ae)
+			}
+		} else if !errors.Is(err, tt.want) {
+			t.Errorf("#%d: got=(%v) want errors.Is(err, %v)", i, err, tt.want)
+		}
+
+		se := new(uber.StatusError)
+		if !errors.As(err, &se) {
+			t.Errorf("#%d: expecting a *uber.StatusError in (%#v)", i, err)
+			continue
+		}
+		if g, w := se.StatusCode, tt.wantStatus; g != w {
+			t.Errorf("#%d: statusCode: got=%d want=%d", i, g, w)
+		}
+		if g, w := se.Code, tt.wantCode; g != w {
+			t.Errorf("#%d: code: got=%q want=%q", i, g, w)
+		}
+	}
+}
+
+// flakyRoundTripper responds with each of the failures in
+// order before handing off requests to the next RoundTripper.
+type flakyRoundTripper struct {
+	mu       sync.Mutex
+	hits     int
+	failures []*errorBody
+	header   http.Header
+	next     http.RoundTripper
+}
+
+var _ http.RoundTripper = (*flakyRoundTripper)(nil)
+
+func (frt *flakyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	frt.mu.Lock()
+	hit := frt.hits
+	frt.hits += 1
+	frt.mu.Unlock()
+
+	if hit >= len(frt.failures) {
+		return frt.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	failure := frt.failures[hit]
+	if failure == nil {
+		return nil, errors.New("connection reset by peer")
+	}
+	resp := uberErrorResp(failure.status, failure.body)
+	for key, values := range frt.header {
+		resp.Header[key] = values
+	}
+	return resp, nil
+}
+
+var (
+	unavailableBody  = &errorBody{status: http.StatusServiceUnavailable, body: "service unavailable"}
+	tooManyReqsBody  = &errorBody{status: http.StatusTooManyRequests, body: "too many requests"}
+	retryRequestBody = &errorBody{
+		status: http.StatusConflict,
+		body:   `{"errors":[{"status":409,"code":"retry_request","title":"Please retry the request."}]}`,
+	}
+)
+
+func TestRetryPolicy(t *testing.T) {
+	rideRequest := &uber.RideRequest{
+		FareID:     "fareID-1",
+		StartPlace: uber.PlaceHome,
+		EndPlace:   uber.PlaceWork,
+	}
+	doRequestRide := func(c *uber.Client) error {
+		_, err := c.RequestRide(rideRequest)
+		return err
+	}
+	doProductByID := func(c *uber.Client) error {
+		_, err := c.ProductByID("a1111c8c-c720-46c3-8534-2fcdd730040d")
+		return err
+	}
+
+	tests := [...]struct {
+		route    string
+		policy   *uber.RetryPolicy
+		failures []*errorBody
+		header   http.Header
+		do       func(*uber.Client) error
+		wantHits int
+		wantErr  bool
+	}{
+		0: {
+			// No retry policy, no retries.
+			route: productByID, do: doProductByID,
+			failures: []*errorBody{unavailableBody},
+			wantHits: 1, wantErr: true,
+		},
+		1: {
+			route: productByID, do: doProductByID,
+			policy:   &uber.RetryPolicy{MaxAttempts: 4},
+			failures: []*errorBody{unavailableBody, nil, tooManyReqsBody},
+			wantHits: 4,
+		},
+		2: {
+			route: productByID, do: doProductByID,
+			policy:   &uber.RetryPolicy{MaxAttempts: 2},
+			failures: []*errorBody{unavailableBody, unavailableBody},
+			wantHits: 2, wantErr: true,
+		},
+		3: {
+			// A non-idempotent request must not be retried on 5XX errors.
+			route: requestRideRoute, do: doRequestRide,
+			policy:   &uber.RetryPolicy{MaxAttempts: 4},
+			failures: []*errorBody{unavailableBody},
+			wantHits: 1, wantErr: true,
+		},
+		4: {
+			// A non-idempotent request must not be retried on network errors.
+			route: requestRideRoute, do: doRequestRide,
+			policy:   &uber.RetryPolicy{MaxAttempts: 4},
+			failures: []*errorBody{nil},
+			wantHits: 1, wantErr: true,
+		},
+		5: {
+			// However Uber can let us know that it is safe to retry.
+			route: requestRideRoute, do: doRequestRide,
+			policy:   &uber.RetryPolicy{MaxAttempts: 4},
+			failures: []*errorBody{retryRequestBody, tooManyReqsBody},
+			wantHits: 3,
+		},
+		6: {
+			route: productByID, do: doProductByID,
+			policy:   &uber.RetryPolicy{MaxAttempts: 2, MaxBackoff: 5 * time.Millisecond},
+			failures: []*errorBody{tooManyReqsBody},
+			header:   http.Header{"Retry-After": []string{"3600"}},
+			wantHits: 2,
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := uber.NewClient(testToken1)
+		if err != nil {
+			t.Fatalf("initializing client; %v", err)
+		}
+
+		flaky := &flakyRoundTripper{
+			failures: tt.failures,
+			header:   tt.header,
+			next:     &tRoundTripper{route: tt.route},
+		}
+		client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, flaky))
+		if tt.policy != nil {
+			policy := *tt.policy
+			policy.InitialBackoff = time.Millisecond
+			client.SetRetryPolicy(&policy)
+		}
+
+		err = tt.do(client)
+		gotErr := err != nil
+		if gotErr != tt.wantErr {
+			t.Errorf("#%d: gotErr=(%v) wantErr=(%v) err=(%v)", i, gotErr, tt.wantErr, err)
+		}
+		if g, w := flaky.hits, tt.wantHits; g != w {
+			t.Errorf("#%d: hits: got=%d want=%d", i, g, w)
+		}
+	}
+}
+
+// headerRoundTripper adds header to every
+// response from the next RoundTripper.
+type headerRoundTripper struct {
+	mu     sync.Mutex
+	hits   int
+	header http.Header
+	next   http.RoundTripper
+}
+
+var _ http.RoundTripper = (*headerRoundTripper)(nil)
+
+func (hrt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	hrt.mu.Lock()
+	hrt.hits += 1
+	hrt.mu.Unlock()
+
+	resp, err := hrt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range hrt.header {
+		resp.Header[key] = values
+	}
+	return resp, nil
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	rateLimitHeader := func(remaining int) http.Header {
+		return http.Header{
+			"X-Rate-Limit-Limit":     []string{"2000"},
+			"X-Rate-Limit-Remaining": []string{strconv.Itoa(remaining)},
+			"X-Rate-Limit-Reset":     []string{strconv.FormatInt(reset.Unix(), 10)},
+		}
+	}
+
+	tests := [...]struct {
+		mode      uber.RateLimitMode
+		remaining int
+		timeout   time.Duration
+
+		want      *uber.RateLimit
+		wantHits  int
+		wantErrOn func(error) bool
+	}{
+		0: {
+			mode: uber.RateLimitTrackOnly, remaining: 1999,
+			want:     &uber.RateLimit{Limit: 2000, Remaining: 1999, Reset: reset},
+			wantHits: 2,
+		},
+		1: {
+			// Even with the budget used up, tracking only must not get in the way.
+			mode: uber.RateLimitTrackOnly, remaining: 0,
+			want:     &uber.RateLimit{Limit: 2000, Remaining: 0, Reset: reset},
+			wantHits: 2,
+		},
+		2: {
+			mode: uber.RateLimitFailFast, remaining: 0,
+			want:     &uber.RateLimit{Limit: 2000, Remaining: 0, Reset: reset},
+			wantHits: 1,
+			wantErrOn: func(err error) bool {
+				return errors.Is(err, uber.ErrRateLimitExhausted)
+			},
+		},
+		3: {
+			mode: uber.RateLimitBlock, remaining: 0, timeout: 50 * time.Millisecond,
+			want:     &uber.RateLimit{Limit: 2000, Remaining: 0, Reset: reset},
+			wantHits: 1,
+			wantErrOn: func(err error) bool {
+				return errors.Is(err, context.DeadlineExceeded)
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := uber.NewClient(testToken1)
+		if err != nil {
+			t.Fatalf("initializing client; %v", err)
+		}
+		if got := client.RateLimitStatus(); got != nil {
+			t.Errorf("#%d: got=%#v want a nil status before any requests", i, got)
+		}
+
+		backend := &headerRoundTripper{
+			header: rateLimitHeader(tt.remaining),
+			next:   &tRoundTripper{route: productByID},
+		}
+		client.SetHTTPRoundTripper(backend)
+		client.SetRateLimitMode(tt.mode)
+
+		if _, err := client.ProductByID("a1111c8c-c720-46c3-8534-2fcdd730040d"); err != nil {
+			t.Errorf("#%d: first request: unexpected err: %v", i, err)
+			continue
+		}
+
+		ctx := context.Background()
+		if tt.timeout > 0 {
+			var cancel func()
+			ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+			defer cancel()
+		}
+		_, err = client.ProductByIDContext(ctx, "a1111c8c-c720-46c3-8534-2fcdd730040d")
+		if tt.wantErrOn != nil {
+			if !tt.wantErrOn(err) {
+				t.Errorf("#%d: second request: unexpected err: %v", i, err)
+			}
+		} else if err != nil {
+			t.Errorf("#%d: second request: unexpected err: %v", i, err)
+		}
+
+		if g, w := backend.hits, tt.wantHits; g != w {
+			t.Errorf("#%d: hits: got=%d want=%d", i, g, w)
+		}
+
+		got := client.RateLimitStatus()
+		if got == nil {
+			t.Errorf("#%d: expecting a non-nil status", i)
+			continue
+		}
+		got.UpdatedAt = time.Time{}
+		if g, w := jsonSerialize(got), jsonSerialize(tt.want); !bytes.Equal(g, w) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, g, w)
+		}
+	}
+}
+
 func TestCancelDelivery(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
@@ -272,437 +613,2411 @@ func TestCancelDelivery(t *testing.T) {
 	}
 }
 
-func TestRequestDelivery(t *testing.T) {
+func TestDeliveryByID(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	backend := &tRoundTripper{route: deliveryRoute}
+	backend := &tRoundTripper{route: deliveryByIDRoute}
 	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
 	client.SetHTTPRoundTripper(transport)
 
 	tests := [...]struct {
-		req     *uber.DeliveryRequest
-		want    *uber.Delivery
-		wantErr bool
+		deliveryID string
+		want       *uber.Delivery
+		wantErr    bool
 	}{
-		0: {
-			req:     &uber.DeliveryRequest{},
-			wantErr: true,
-		},
-		1: {
-			req:     nil,
-			wantErr: true,
-		},
-		2: {
-			req:     &uber.DeliveryRequest{},
-			wantErr: true,
-		},
-		3: {
-			req: &uber.DeliveryRequest{
-				Pickup: &uber.Endpoint{
-					Contact: &uber.Contact{
-						CompanyName:          "orijtech",
-						Email:                "deliveries@orijtech.com",
-						SendSMSNotifications: true,
-					},
-					Location: &uber.Location{
-						PrimaryAddress: "Empire State Building",
-						State:          "NY",
-						Country:        "US",
-					},
-					SpecialInstructions: "Please ask guest services for \"I Man\"",
-				},
-				Dropoff: &uber.Endpoint{
-					Contact: &uber.Contact{
-						FirstName:   "delivery",
-						LastName:    "bot",
-						CompanyName: "Uber",
+		0: {deliveryID: "", wantErr: true},
+		1: {deliveryID: "     ", wantErr: true},
+		2: {deliveryID: "unknown", wantErr: true},
+		3: {deliveryID: deliveryID1, want: deliveryResponseFromFile(deliveryResponsePath(deliveryResponseID1))},
+	}
 
-						SendEmailNotifications: true,
-					},
-					Location: &uber.Location{
-						PrimaryAddress:   "530 W 113th Street",
-						SecondaryAddress: "Floor 2",
-						Country:          "US",
-						PostalCode:       "10025",
-						State:            "NY",
-					},
-				},
-				Items: []*uber.Item{
-					{
-						Title:    "phone chargers",
-						Quantity: 10,
-					},
-					{
-						Title:    "Blue prints",
-						Fragile:  true,
-						Quantity: 1,
-					},
-				},
-			},
-			want: deliveryResponseFromFile(deliveryResponsePath(deliveryResponseID1)),
-		},
+	for i, tt := range tests {
+		delivery, err := client.DeliveryByID(tt.deliveryID)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(delivery, tt.want) {
+			t.Errorf("#%d:\ngot:  %#v\nwant: %#v", i, delivery, tt.want)
+		}
+		if g, w := delivery.FeeMoney().String(), "5.00 USD"; g != w {
+			t.Errorf("#%d: fee: got=%q want=%q", i, g, w)
+		}
+	}
+}
+
+func TestDeliveryReceipt(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: deliveryReceiptRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	want := new(uber.DeliveryReceipt)
+	if err := readFromFileAndDeserialize(deliveryReceiptPath(deliveryResponseID1), want); err != nil {
+		t.Fatalf("reading receipt fixture: %v", err)
+	}
+
+	tests := [...]struct {
+		deliveryID string
+		want       *uber.DeliveryReceipt
+		wantErr    bool
+	}{
+		0: {deliveryID: "", wantErr: true},
+		1: {deliveryID: "unknown", wantErr: true},
+		2: {deliveryID: deliveryID1, want: want},
 	}
 
 	for i, tt := range tests {
-		dres, err := client.RequestDelivery(tt.req)
+		receipt, err := client.DeliveryReceipt(tt.deliveryID)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		gotBlob, wantBlob := jsonSerialize(receipt), jsonSerialize(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		}
+		if len(receipt.Charges) != 2 || len(receipt.ChargeAdjustments) != 1 {
+			t.Errorf("#%d: got %d charges and %d adjustments, want 2 and 1", i, len(receipt.Charges), len(receipt.ChargeAdjustments))
+		}
+		if g, w := receipt.TotalChargedMoney().String(), "3.50 USD"; g != w {
+			t.Errorf("#%d: total charged: got=%q want=%q", i, g, w)
+		}
+	}
+}
+
+func TestRateDelivery(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: rateDeliveryRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	fixture := new(uber.DeliveryRating)
+	if err := readFromFileAndDeserialize(deliveryRatingPath(deliveryResponseID1), fixture); err != nil {
+		t.Fatalf("reading rating fixture: %v", err)
+	}
+	withoutType := *fixture
+	withoutType.RatingType = ""
+
+	tests := [...]struct {
+		deliveryID string
+		rating     *uber.DeliveryRating
+		wantErr    bool
+	}{
+		0: {deliveryID: "", rating: fixture, wantErr: true},
+		1: {deliveryID: deliveryID1, rating: nil, wantErr: true},
+		2: {deliveryID: deliveryID1, rating: &uber.DeliveryRating{Waypoint: "courier", RatingValue: 1}, wantErr: true},
+		3: {deliveryID: deliveryID1, rating: &uber.DeliveryRating{Waypoint: uber.PickupWaypoint, RatingValue: 5}, wantErr: true},
+		4: {deliveryID: deliveryID1, rating: &uber.DeliveryRating{Waypoint: uber.PickupWaypoint, RatingType: "stars"}, wantErr: true},
+		5: {
+			deliveryID: deliveryID1,
+			rating: &uber.DeliveryRating{
+				Waypoint: uber.Dropoffpoint, RatingValue: 1, Comments: strings.Repeat("a", 2501),
+			},
+			wantErr: true,
+		},
+		6: {deliveryID: "unknown", rating: fixture, wantErr: true},
+		7: {deliveryID: deliveryID1, rating: fixture},
+
+		// The rating type defaults to binary.
+		8: {deliveryID: deliveryID1, rating: &withoutType},
+	}
+
+	for i, tt := range tests {
+		err := client.RateDelivery(tt.deliveryID, tt.rating)
+		gotErr := err != nil
+		if gotErr != tt.wantErr {
+			t.Errorf("#%d: gotErr=(%v) wantErr=(%v) err=(%v)", i, gotErr, tt.wantErr, err)
+		}
+	}
+	if withoutType.RatingType != "" {
+		t.Errorf("the rating passed in was modified")
+	}
+}
+
+var deliveryLifecycleSnapshots = []string{
+	0: `{"delivery_id":"d1","status":"processing","pickup":{"eta":10},"dropoff":{"eta":30}}`,
+	1: `{"delivery_id":"d1","status":"en_route_to_pickup","courier":{"first_name":"Ann","phone":{"number":"+14081234567"},
+		"location":{"latitude":40.7484,"longitude":-73.9857,"bearing":90}},"pickup":{"eta":8},"dropoff":{"eta":30}}`,
+	2: `{"delivery_id":"d1","status":"en_route_to_pickup","courier":{"first_name":"Ann","phone":{"number":"+14081234567"},
+		"location":{"latitude":40.7490,"longitude":-73.9860,"bearing":90}},"pickup":{"eta":8},"dropoff":{"eta":30}}`,
+	// A stale response that must be discarded.
+	3: `{"delivery_id":"d1","status":"processing","pickup":{"eta":10},"dropoff":{"eta":30}}`,
+	4: `{"delivery_id":"d1","status":"en_route_to_dropoff","courier":{"first_name":"Ann","phone":{"number":"+14081234567"},
+		"location":{"latitude":40.7490,"longitude":-73.9860,"bearing":90}},"pickup":{"eta":8},"dropoff":{"eta":12}}`,
+	5: `{"delivery_id":"d1","status":"completed","courier":{"first_name":"Ann","phone":{"number":"+14081234567"},
+		"location":{"latitude":40.8050,"longitude":-73.9650,"bearing":0}},"pickup":{"eta":8},"dropoff":{"eta":12}}`,
+}
+
+func TestTrackDelivery(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	if _, err := client.TrackDelivery(context.Background(), "  ", nil); err == nil {
+		t.Errorf("expecting an error for a blank deliveryID")
+	}
+
+	backend := &tripSequenceRoundTripper{snapshots: deliveryLifecycleSnapshots}
+	client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, backend))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := client.TrackDelivery(ctx, "d1", &uber.TrackOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var got []string
+	for ev := range events {
+		if ev.Err != nil {
+			t.Errorf("unexpected event err: %v", ev.Err)
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s@%s<-%s", ev.Kind, ev.Delivery.Status, ev.PreviousStatus))
+	}
+
+	want := []string{
+		"status@processing<-",
+		"eta@processing<-",
+		"status@en_route_to_pickup<-processing",
+		"courier@en_route_to_pickup<-processing",
+		"location@en_route_to_pickup<-processing",
+		"eta@en_route_to_pickup<-processing",
+		"location@en_route_to_pickup<-en_route_to_pickup",
+		"status@en_route_to_dropoff<-en_route_to_pickup",
+		"eta@en_route_to_dropoff<-en_route_to_pickup",
+		"status@completed<-en_route_to_dropoff",
+		"location@completed<-en_route_to_dropoff",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events:\ngot:  %q\nwant: %q", got, want)
+	}
+	if g, w := backend.hits, len(deliveryLifecycleSnapshots); g != w {
+		t.Errorf("polls: got=%d want=%d", g, w)
+	}
+}
+
+func TestCancelRide(t *testing.T) {
+	authdClient, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	cancelCurrentTrip := func(c *uber.Client) error { return c.CancelCurrentTrip() }
+	cancelRide := func(requestID string) func(*uber.Client) error {
+		return func(c *uber.Client) error { return c.CancelRide(requestID) }
+	}
+
+	tests := [...]struct {
+		client  *uber.Client
+		cancel  func(*uber.Client) error
+		wantErr bool
+
+		// wantErrIs if set is the error that
+		// the returned error must match.
+		wantErrIs error
+	}{
+		0: {client: authdClient, cancel: cancelCurrentTrip},
+		1: {client: authdClient, cancel: cancelRide(ride1)},
+		2: {client: authdClient, cancel: cancelRide("   "), wantErr: true},
+		3: {client: new(uber.Client), cancel: cancelCurrentTrip, wantErr: true},
+		4: {client: authdClient, cancel: cancelRide("completed"), wantErr: true, wantErrIs: uber.ErrTripAlreadyCompleted},
+		5: {client: authdClient, cancel: cancelRide("past-grace-period"), wantErr: true, wantErrIs: uber.ErrCancellationFee},
+		6: {client: authdClient, cancel: cancelRide("no-current-trip"), wantErr: true, wantErrIs: uber.ErrNoCurrentTrip},
+	}
+
+	for i, tt := range tests {
+		tt.client.SetHTTPRoundTripper(&tRoundTripper{route: cancelRideRoute})
+
+		err := tt.cancel(tt.client)
+		gotErr := err != nil
+		if gotErr != tt.wantErr {
+			t.Errorf("#%d: gotErr=(%v) wantErr=(%v) err=(%v)", i, gotErr, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("#%d: got err=(%v) want=(%v)", i, err, tt.wantErrIs)
+		}
+	}
+}
+
+func TestUpdateTripDestination(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+	client.SetHTTPRoundTripper(&tRoundTripper{route: updateTripDestinationRoute})
+
+	tests := [...]struct {
+		requestID string // Blank for the current trip.
+		du        *uber.DestinationUpdate
+		wantErr   bool
+	}{
+		0: {du: &uber.DestinationUpdate{EndPlace: uber.PlaceHome}},
+		1: {requestID: ride1, du: &uber.DestinationUpdate{EndLatitude: 37.7759792, EndLongitude: -122.41823}},
+		2: {du: nil, wantErr: true},
+		3: {du: &uber.DestinationUpdate{}, wantErr: true},
+		4: {du: &uber.DestinationUpdate{EndPlace: "gym"}, wantErr: true},
+		5: {requestID: "   ", du: &uber.DestinationUpdate{EndPlace: uber.PlaceWork}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var trip *uber.Trip
+		var err error
+		if tt.requestID == "" {
+			trip, err = client.UpdateCurrentTripDestination(tt.du)
+		} else {
+			trip, err = client.UpdateTripDestination(tt.requestID, tt.du)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: wantErr", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if trip == nil || reflect.DeepEqual(blankTrip, trip) {
+			t.Errorf("#%d: want a non-blank trip", i)
+		}
+	}
+}
+
+// tripSequenceRoundTripper serves the trip snapshots in order,
+// repeating the last one once all of them have been served.
+type tripSequenceRoundTripper struct {
+	mu        sync.Mutex
+	snapshots []string
+	hits      int
+}
+
+var _ http.RoundTripper = (*tripSequenceRoundTripper)(nil)
+
+func (tsrt *tripSequenceRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "GET"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	tsrt.mu.Lock()
+	defer tsrt.mu.Unlock()
+
+	i := tsrt.hits
+	if i >= len(tsrt.snapshots) {
+		i = len(tsrt.snapshots) - 1
+	}
+	tsrt.hits += 1
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(strings.NewReader(tsrt.snapshots[i]))
+	return resp, nil
+}
+
+var rideLifecycleSnapshots = []string{
+	0: `{"request_id":"a1111c8c","status":"processing"}`,
+	1: `{"request_id":"a1111c8c","status":"processing"}`,
+	2: `{"request_id":"a1111c8c","status":"accepted","driver":{"name":"Bob","rating":5},
+		"vehicle":{"make":"Bugatti","model":"Veyron","license_plate":"I<3Uber"},
+		"location":{"latitude":37.3382,"longitude":-121.8863,"bearing":328},"pickup":{"eta":5}}`,
+	3: `{"request_id":"a1111c8c","status":"accepted","driver":{"name":"Bob","rating":5},
+		"vehicle":{"make":"Bugatti","model":"Veyron","license_plate":"I<3Uber"},
+		"location":{"latitude":37.3350,"longitude":-121.8870,"bearing":300},"pickup":{"eta":3}}`,
+	4: `{"request_id":"a1111c8c","status":"arriving","driver":{"name":"Bob","rating":5},
+		"vehicle":{"make":"Bugatti","model":"Veyron","license_plate":"I<3Uber"},
+		"location":{"latitude":37.3350,"longitude":-121.8870,"bearing":300},"pickup":{"eta":3}}`,
+	// A stale response that must be discarded.
+	5: `{"request_id":"a1111c8c","status":"processing"}`,
+	6: `{"request_id":"a1111c8c","status":"in_progress","driver":{"name":"Bob","rating":5},
+		"vehicle":{"make":"Bugatti","model":"Veyron","license_plate":"I<3Uber"},
+		"location":{"latitude":37.3303,"longitude":-121.8890,"bearing":300},"pickup":{"eta":3}}`,
+	7: `{"request_id":"a1111c8c","status":"completed","driver":{"name":"Bob","rating":5},
+		"vehicle":{"make":"Bugatti","model":"Veyron","license_plate":"I<3Uber"},
+		"location":{"latitude":37.3303,"longitude":-121.8890,"bearing":300},"pickup":{"eta":3}}`,
+}
+
+func TestStatusTransitions(t *testing.T) {
+	tests := [...]struct {
+		from, to uber.Status
+		want     bool
+	}{
+		0:  {uber.StatusProcessing, uber.StatusAccepted, true},
+		1:  {uber.StatusAccepted, uber.StatusAccepted, true},
+		2:  {uber.StatusProcessing, uber.StatusInProgress, true},
+		3:  {uber.StatusArriving, uber.StatusAccepted, true},
+		4:  {uber.StatusInProgress, uber.StatusCompleted, true},
+		5:  {uber.StatusCompleted, uber.StatusReceiptReady, true},
+		6:  {uber.StatusProcessing, uber.StatusReceiptReady, true},
+		7:  {uber.StatusInProgress, uber.StatusArriving, false},
+		8:  {uber.StatusInProgress, uber.StatusRiderCanceled, false},
+		9:  {uber.StatusCompleted, uber.StatusCompleted, false},
+		10: {uber.StatusRiderCanceled, uber.StatusAccepted, false},
+		11: {uber.StatusNoDriversAvailable, uber.StatusReceiptReady, false},
+		12: {uber.StatusProcessing, "unknown", false},
+		13: {uber.StatusArriving, uber.StatusArriving, true},
+		14: {uber.StatusInProgress, uber.StatusInProgress, false},
+	}
+
+	for i, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("#%d: %q => %q got=%v want=%v", i, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	deliveryTests := [...]struct {
+		from, to uber.DeliveryStatus
+		want     bool
+	}{
+		0: {uber.DeliveryStatusProcessing, uber.DeliveryStatusEnRouteToPickup, true},
+		1: {uber.DeliveryStatusProcessing, uber.DeliveryStatusCompleted, true},
+		2: {uber.DeliveryStatusAtDropoff, uber.DeliveryStatusReturned, true},
+		3: {uber.DeliveryStatusEnRouteToDropoff, uber.DeliveryStatusClientCanceled, false},
+		4: {uber.DeliveryStatusCompleted, uber.DeliveryStatusReturning, false},
+		5: {uber.DeliveryStatusAtPickup, uber.DeliveryStatusAtPickup, false},
+	}
+
+	for i, tt := range deliveryTests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("delivery #%d: %q => %q got=%v want=%v", i, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	terminal := map[uber.Status]bool{
+		uber.StatusCompleted: true, uber.StatusReceiptReady: true, uber.StatusRiderCanceled: true,
+		uber.StatusDriverCanceled: true, uber.StatusNoDriversAvailable: true,
+	}
+	for _, status := range []uber.Status{
+		uber.StatusProcessing, uber.StatusAccepted, uber.StatusArriving, uber.StatusInProgress,
+		uber.StatusCompleted, uber.StatusReceiptReady, uber.StatusRiderCanceled,
+		uber.StatusDriverCanceled, uber.StatusNoDriversAvailable,
+	} {
+		if g, w := status.IsTerminal(), terminal[status]; g != w {
+			t.Errorf("%q: IsTerminal got=%v want=%v", status, g, w)
+		}
+		if g, w := status.IsActive(), !terminal[status]; g != w {
+			t.Errorf("%q: IsActive got=%v want=%v", status, g, w)
+		}
+	}
+}
+
+func TestTrackRide(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	if _, err := client.TrackRide(context.Background(), "  ", nil); err == nil {
+		t.Errorf("expecting an error for a blank requestID")
+	}
+
+	backend := &tripSequenceRoundTripper{snapshots: rideLifecycleSnapshots}
+	client.SetHTTPRoundTripper(backend)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := client.TrackRide(ctx, "a1111c8c", &uber.TrackOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var got []string
+	for ev := range events {
+		if ev.Err != nil {
+			t.Errorf("unexpected event err: %v", ev.Err)
+		}
+		got = append(got, fmt.Sprintf("%s@%s<-%s", ev.Kind, ev.Trip.Status, ev.PreviousStatus))
+	}
+
+	want := []string{
+		"status@processing<-",
+		"status@accepted<-processing",
+		"driver@accepted<-processing",
+		"vehicle@accepted<-processing",
+		"location@accepted<-processing",
+		"eta@accepted<-processing",
+		"location@accepted<-accepted",
+		"eta@accepted<-accepted",
+		"status@arriving<-accepted",
+		"status@in_progress<-arriving",
+		"location@in_progress<-arriving",
+		"status@completed<-in_progress",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events:\ngot:  %q\nwant: %q", got, want)
+	}
+	if g, w := backend.hits, len(rideLifecycleSnapshots); g != w {
+		t.Errorf("polls: got=%d want=%d", g, w)
+	}
+}
+
+func TestTrackRideStops(t *testing.T) {
+	// Tracking must end once the allowed number of consecutive errors is reached.
+	unauthdClient := new(uber.Client)
+	unauthdClient.SetHTTPRoundTripper(&tripSequenceRoundTripper{snapshots: rideLifecycleSnapshots})
+	events, err := unauthdClient.TrackRide(context.Background(), "a1111c8c", &uber.TrackOptions{
+		PollInterval:         time.Millisecond,
+		MaxConsecutiveErrors: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	nErrs := 0
+	for ev := range events {
+		if ev.Kind != uber.RideEventError || ev.Err == nil {
+			t.Errorf("got event %q err=%v, want only errors", ev.Kind, ev.Err)
+		}
+		nErrs += 1
+	}
+	if nErrs != 3 {
+		t.Errorf("errors: got=%d want=3", nErrs)
+	}
+
+	// Tracking must end once the context is done, even for an ongoing trip.
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+	client.SetHTTPRoundTripper(&tripSequenceRoundTripper{snapshots: rideLifecycleSnapshots[:3]})
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err = client.TrackRide(ctx, "a1111c8c", &uber.TrackOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	<-events
+	cancel()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("tracking did not stop after the context was canceled")
+		}
+	}
+}
+
+const (
+	surgingProductID     = "surging"
+	surgeConfirmationID1 = "e100a670"
+)
+
+func TestRequestRideSurge(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+	backend := &tRoundTripper{route: requestRideRoute}
+	client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, backend))
+
+	errDeclined := errors.New("surge declined")
+	tests := [...]struct {
+		confirmationID string
+		promptErr      error
+		noPrompt       bool
+
+		wantErrIs error
+	}{
+		0: {confirmationID: surgeConfirmationID1},
+		1: {promptErr: errDeclined, wantErrIs: errDeclined},
+		2: {noPrompt: true, wantErrIs: uber.ErrSurge},
+		3: {confirmationID: "  ", wantErrIs: uber.ErrSurge},
+	}
+
+	for i, tt := range tests {
+		var prompts []*uber.FareEstimate
+		rreq := &uber.RideRequest{
+			FareID:     "fare-1",
+			ProductID:  surgingProductID,
+			StartPlace: uber.PlaceHome,
+			EndPlace:   uber.PlaceWork,
+		}
+		if !tt.noPrompt {
+			rreq.PromptOnSurge = func(fe *uber.FareEstimate) (string, error) {
+				prompts = append(prompts, fe)
+				return tt.confirmationID, tt.promptErr
+			}
+		}
+
+		ride, err := client.RequestRide(rreq)
+		if tt.wantErrIs != nil {
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("#%d: got err=(%v) want=(%v)", i, err, tt.wantErrIs)
+			}
+		} else if err != nil || ride == nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+		}
+		if rreq.SurgeConfirmationID != "" {
+			t.Errorf("#%d: the original request must not be modified", i)
+		}
+
+		if tt.noPrompt {
+			continue
+		}
+		if len(prompts) != 1 {
+			t.Errorf("#%d: prompts: got=%d want=1", i, len(prompts))
+			continue
+		}
+		fe := prompts[0]
+		if g, w := fe.SurgeConfirmationID, surgeConfirmationID1; g != w {
+			t.Errorf("#%d: surgeConfirmationID got=%q want=%q", i, g, w)
+		}
+		if g, w := fe.SurgeConfirmationURL, "https://api.uber.com/surge-confirmations/e100a670"; g != w {
+			t.Errorf("#%d: surgeConfirmationURL got=%q want=%q", i, g, w)
+		}
+	}
+}
+
+// fareRefreshRoundTripper hands out a new upfront fare on every estimate
+// and rejects ride requests for fares that it considers expired.
+type fareRefreshRoundTripper struct {
+	mu        sync.Mutex
+	estimates int
+	rideFares []string
+
+	// expiredFares lists the fareIDs that Uber deems expired.
+	// If expireAll is set, every fare is deemed expired.
+	expiredFares map[string]bool
+	expireAll    bool
+}
+
+var _ http.RoundTripper = (*fareRefreshRoundTripper)(nil)
+
+func (frt *fareRefreshRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "POST"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	frt.mu.Lock()
+	defer frt.mu.Unlock()
+
+	if strings.HasSuffix(req.URL.Path, "/requests/estimate") {
+		frt.estimates += 1
+		body := fmt.Sprintf(`{"fare":{"value":5.73,"fare_id":"fare-%d","expires_at":%d,"currency_code":"USD"}}`,
+			frt.estimates, time.Now().Add(2*time.Minute).Unix())
+		resp := makeResp("200 OK", http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(body))
+		return resp, nil
+	}
+
+	rreq := new(uber.RideRequest)
+	if err := json.NewDecoder(req.Body).Decode(rreq); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	frt.rideFares = append(frt.rideFares, rreq.FareID)
+	if frt.expireAll || frt.expiredFares[rreq.FareID] {
+		return uberErrorResp(http.StatusConflict, `{"errors":[{"status":409,"code":"fare_expired","title":"The fare has expired."}]}`), nil
+	}
+	return responseFromFileContent(rideFromPath(ride1)), nil
+}
+
+func TestRequestRideFareRefresh(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	tests := [...]struct {
+		fareID        string
+		fareExpiresAt int64
+		maxRefreshes  int
+		expiredFares  map[string]bool
+		expireAll     bool
+
+		wantErrIs     error
+		wantEstimates int
+		wantRideFares []string
+	}{
+		0: {
+			// Expired before the ride is even requested.
+			fareID: "stale", fareExpiresAt: time.Now().Add(-time.Minute).Unix(),
+			wantEstimates: 1, wantRideFares: []string{"fare-1"},
+		},
+		1: {
+			// Uber reports that the fare expired.
+			fareID: "rejected", expiredFares: map[string]bool{"rejected": true, "fare-1": true},
+			wantEstimates: 2, wantRideFares: []string{"rejected", "fare-1", "fare-2"},
+		},
+		2: {
+			fareID: "rejected", expireAll: true,
+			wantErrIs: uber.ErrFareExpired, wantEstimates: uber.DefaultMaxFareRefreshes,
+			wantRideFares: []string{"rejected", "fare-1", "fare-2", "fare-3"},
+		},
+		3: {
+			fareID: "stale", fareExpiresAt: time.Now().Add(-time.Minute).Unix(), maxRefreshes: -1,
+			wantErrIs: uber.ErrFareExpired,
+		},
+		4: {
+			// Fares that have not yet expired are used as is.
+			fareID: "fresh", fareExpiresAt: time.Now().Add(time.Minute).Unix(),
+			wantRideFares: []string{"fresh"},
+		},
+	}
+
+	for i, tt := range tests {
+		backend := &fareRefreshRoundTripper{expiredFares: tt.expiredFares, expireAll: tt.expireAll}
+		client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, backend))
+
+		var prompted []string
+		ride, err := client.RequestRide(&uber.RideRequest{
+			FareID:           tt.fareID,
+			FareExpiresAt:    tt.fareExpiresAt,
+			MaxFareRefreshes: tt.maxRefreshes,
+			StartPlace:       uber.PlaceHome,
+			EndPlace:         uber.PlaceWork,
+			PromptOnFare: func(uf *uber.UpfrontFare) error {
+				prompted = append(prompted, string(uf.Fare.ID))
+				return nil
+			},
+		})
+		if tt.wantErrIs != nil {
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("#%d: got err=(%v) want=(%v)", i, err, tt.wantErrIs)
+			}
+		} else if err != nil || ride == nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+		}
+
+		if g, w := backend.estimates, tt.wantEstimates; g != w {
+			t.Errorf("#%d: estimates: got=%d want=%d", i, g, w)
+		}
+		if g, w := len(prompted), tt.wantEstimates; g != w {
+			t.Errorf("#%d: prompts: got=%d want=%d", i, g, w)
+		}
+		if g, w := backend.rideFares, tt.wantRideFares; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: requested fares:\ngot:  %q\nwant: %q", i, g, w)
+		}
+	}
+}
+
+func TestRequestDelivery(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: deliveryRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		req     *uber.DeliveryRequest
+		want    *uber.Delivery
+		wantErr bool
+	}{
+		0: {
+			req:     &uber.DeliveryRequest{},
+			wantErr: true,
+		},
+		1: {
+			req:     nil,
+			wantErr: true,
+		},
+		2: {
+			req:     &uber.DeliveryRequest{},
+			wantErr: true,
+		},
+		3: {
+			req: &uber.DeliveryRequest{
+				Pickup: &uber.Endpoint{
+					Contact: &uber.Contact{
+						CompanyName:          "orijtech",
+						Email:                "deliveries@orijtech.com",
+						SendSMSNotifications: true,
+					},
+					Location: &uber.Location{
+						PrimaryAddress: "Empire State Building",
+						State:          "NY",
+						Country:        "US",
+					},
+					SpecialInstructions: "Please ask guest services for \"I Man\"",
+				},
+				Dropoff: &uber.Endpoint{
+					Contact: &uber.Contact{
+						FirstName:   "delivery",
+						LastName:    "bot",
+						CompanyName: "Uber",
+
+						SendEmailNotifications: true,
+					},
+					Location: &uber.Location{
+						PrimaryAddress:   "530 W 113th Street",
+						SecondaryAddress: "Floor 2",
+						Country:          "US",
+						PostalCode:       "10025",
+						State:            "NY",
+					},
+				},
+				Items: []*uber.Item{
+					{
+						Title:    "phone chargers",
+						Quantity: 10,
+					},
+					{
+						Title:    "Blue prints",
+						Fragile:  true,
+						Quantity: 1,
+					},
+				},
+			},
+			want: deliveryResponseFromFile(deliveryResponsePath(deliveryResponseID1)),
+		},
+	}
+
+	for i, tt := range tests {
+		dres, err := client.RequestDelivery(tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+
+		if dres == nil {
+			t.Errorf("#%d: expecting non-nil delivery response", i)
+			continue
+		}
+		gotBytes := jsonSerialize(dres)
+		wantBytes := jsonSerialize(tt.want)
+		if !bytes.Equal(gotBytes, wantBytes) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBytes, wantBytes)
+		}
+	}
+}
+
+// inflightRoundTripper records the maximum number
+// of requests that it was concurrently serving.
+type inflightRoundTripper struct {
+	mu          sync.Mutex
+	base        http.RoundTripper
+	hits        int
+	inflight    int
+	maxInflight int
+}
+
+var _ http.RoundTripper = (*inflightRoundTripper)(nil)
+
+func (irt *inflightRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	irt.mu.Lock()
+	irt.hits += 1
+	irt.inflight += 1
+	if irt.inflight > irt.maxInflight {
+		irt.maxInflight = irt.inflight
+	}
+	irt.mu.Unlock()
+
+	defer func() {
+		irt.mu.Lock()
+		irt.inflight -= 1
+		irt.mu.Unlock()
+	}()
+
+	// Linger so that concurrent requests overlap.
+	time.Sleep(5 * time.Millisecond)
+	return irt.base.RoundTrip(req)
+}
+
+func gizmoDeliveryRequest(quoteID string) *uber.DeliveryRequest {
+	return &uber.DeliveryRequest{
+		QuoteID: quoteID,
+		Pickup: &uber.Endpoint{
+			Contact:  &uber.Contact{CompanyName: "Gizmo Shop", Email: "contact@uber.com"},
+			Location: &uber.Location{PrimaryAddress: "636 W 28th Street", State: "NY", Country: "US"},
+		},
+		Dropoff: &uber.Endpoint{
+			Contact:  &uber.Contact{FirstName: "Calvin", LastName: "Lee"},
+			Location: &uber.Location{PrimaryAddress: "530 W 113th Street", State: "NY", Country: "US"},
+		},
+		Items: []*uber.Item{{Title: "Shoes", Quantity: 1}},
+	}
+}
+
+func TestRequestDeliveries(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &inflightRoundTripper{
+		base: uberOAuth2.TransportWithBase(testOAuth2Token1, &tRoundTripper{route: deliveryRoute}),
+	}
+	client.SetHTTPRoundTripper(backend)
+
+	ctx := context.Background()
+	if _, err := client.RequestDeliveries(ctx, nil); err == nil {
+		t.Errorf("expecting an error for no requests")
+	}
+
+	// No request must be submitted if any of them is invalid.
+	invalid := []*uber.DeliveryRequest{gizmoDeliveryRequest(""), {}, gizmoDeliveryRequest("")}
+	if _, err := client.RequestDeliveries(ctx, invalid); err == nil {
+		t.Errorf("expecting an error for an invalid request")
+	}
+	if backend.hits != 0 {
+		t.Errorf("got %d requests submitted despite an invalid request", backend.hits)
+	}
+
+	var reqs []*uber.DeliveryRequest
+	for i := 0; i < 12; i++ {
+		reqs = append(reqs, gizmoDeliveryRequest(""))
+	}
+	// The backend rejects unknown quotes.
+	reqs[3].QuoteID = "unknown-quote"
+
+	db, err := client.RequestDeliveries(ctx, reqs)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if g, w := backend.hits, len(reqs); g != w {
+		t.Errorf("requests: got=%d want=%d", g, w)
+	}
+	if backend.maxInflight > 5 {
+		t.Errorf("got %d concurrent requests, want at most 5", backend.maxInflight)
+	}
+
+	want := deliveryResponseFromFile(deliveryResponsePath(deliveryResponseID1))
+	for i := range reqs {
+		delivery, err := db.Deliveries[i], db.Errs[i]
+		if i == 3 {
+			if delivery != nil || err == nil {
+				t.Errorf("#%d: got delivery=%v err=%v, want only an error", i, delivery, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(delivery, want) {
+			t.Errorf("#%d:\ngot:  %#v\nwant: %#v", i, delivery, want)
+		}
+	}
+	if db.Err() == nil {
+		t.Errorf("expecting the batch to report the failed request")
+	}
+
+	if g, w := db.Batch.ID, want.Batch.ID; g != w {
+		t.Errorf("batch ID: got=%q want=%q", g, w)
+	}
+	if g, w := db.Batch.Count, int64(len(reqs)-1); g != w {
+		t.Errorf("batch count: got=%d want=%d", g, w)
+	}
+	if g, w := len(db.Batch.Deliveries), len(reqs)-1; g != w {
+		t.Errorf("batch deliveries: got=%d want=%d", g, w)
+	}
+}
+
+// batchIDsRoundTripper creates deliveries in the batch
+// named by the order reference ID of their requests.
+type batchIDsRoundTripper struct{}
+
+var _ http.RoundTripper = batchIDsRoundTripper{}
+
+func (batchIDsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	dreq := new(uber.DeliveryRequest)
+	if err := json.NewDecoder(req.Body).Decode(dreq); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	delivery := &uber.Delivery{ID: "delivery-" + dreq.OrderReferenceID}
+	if dreq.OrderReferenceID != "" {
+		delivery.Batch = &uber.Batch{ID: dreq.OrderReferenceID}
+	}
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(jsonSerialize(delivery)))
+	return resp, nil
+}
+
+func TestRequestDeliveriesBatchID(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+	client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, batchIDsRoundTripper{}))
+
+	tests := [...]struct {
+		batchIDs []string
+		want     string
+	}{
+		0: {batchIDs: []string{"b1", "b1", "b1"}, want: "b1"},
+		1: {batchIDs: []string{"b1", "b2", "b1"}},
+		2: {batchIDs: []string{"b1", "", "b1"}},
+		3: {batchIDs: []string{"", "b1"}},
+		4: {batchIDs: []string{"", ""}},
+	}
+
+	for i, tt := range tests {
+		var reqs []*uber.DeliveryRequest
+		for _, batchID := range tt.batchIDs {
+			req := gizmoDeliveryRequest("")
+			req.OrderReferenceID = batchID
+			reqs = append(reqs, req)
+		}
+		db, err := client.RequestDeliveries(context.Background(), reqs)
+		if err == nil {
+			err = db.Err()
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if g, w := db.Batch.ID, tt.want; g != w {
+			t.Errorf("#%d: batch ID: got=%q want=%q", i, g, w)
+		}
+		if g, w := db.Batch.Count, int64(len(reqs)); g != w {
+			t.Errorf("#%d: batch count: got=%d want=%d", i, g, w)
+		}
+	}
+}
+
+func TestCancelBatch(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: cancelDeliveryRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		batch   *uber.Batch
+		wantErr bool
+	}{
+		0: {batch: nil, wantErr: true},
+		1: {batch: &uber.Batch{ID: "b1", Deliveries: []string{deliveryID1, deliveryID2}}},
+		2: {batch: &uber.Batch{ID: "b2", Deliveries: []string{deliveryID1, "unknown", deliveryID2}}, wantErr: true},
+		3: {batch: &uber.Batch{ID: "b3", Deliveries: []string{deliveryID1, "  "}}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		err := client.CancelBatch(context.Background(), tt.batch)
+		gotErr := err != nil
+		if gotErr != tt.wantErr {
+			t.Errorf("#%d: gotErr=(%v) wantErr=(%v) err=(%v)", i, gotErr, tt.wantErr, err)
+		}
+	}
+}
+
+func fieldPaths(err error) []string {
+	var ve *uber.ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	var paths []string
+	for _, fe := range ve.Fields {
+		paths = append(paths, fe.Path)
+	}
+	return paths
+}
+
+func TestDeliveryRequestValidate(t *testing.T) {
+	withPhone := func(number string) *uber.DeliveryRequest {
+		dr := gizmoDeliveryRequest("")
+		dr.Dropoff.Contact.Phone = &uber.Phone{Number: number}
+		return dr
+	}
+	noDropoff := gizmoDeliveryRequest("")
+	noDropoff.Dropoff = nil
+	badItems := gizmoDeliveryRequest("")
+	badItems.Items = []*uber.Item{
+		{Title: "Shoes", Quantity: 1, WidthInches: 7, HeightInches: 5, LengthInches: 14.5, CurrencyCode: "USD"},
+		{Title: " ", Quantity: 0, WidthInches: -1, CurrencyCode: "usd"},
+		nil,
+	}
+	badContacts := gizmoDeliveryRequest("")
+	badContacts.Pickup.Contact = &uber.Contact{Email: "Gizmo Shop <contact@uber.com>"}
+	badContacts.Dropoff.Contact.Email = "calvin.lee"
+	badContacts.Dropoff.Location = &uber.Location{Latitude: 91, Longitude: -181}
+	badContacts.Pickup.SpecialInstructions = otils.NullableString(strings.Repeat("a", 257))
+
+	tests := [...]struct {
+		req       *uber.DeliveryRequest
+		wantPaths []string
+
+		// wantErrIs if set is the error that
+		// the returned error must match.
+		wantErrIs error
+	}{
+		0: {req: gizmoDeliveryRequest("")},
+		1: {req: withPhone("+14081234567")},
+		2: {req: nil, wantPaths: []string{"items", "pickup", "dropoff"}},
+		3: {req: noDropoff, wantPaths: []string{"dropoff"}},
+		4: {req: withPhone(""), wantPaths: []string{"dropoff.contact.phone.number"}},
+		5: {req: withPhone("(408) 123-4567"), wantPaths: []string{"dropoff.contact.phone.number"}},
+		6: {req: withPhone("+0123456789"), wantPaths: []string{"dropoff.contact.phone.number"}},
+		7: {
+			req: badItems,
+			wantPaths: []string{
+				"items[1].title", "items[1].quantity", "items[1].width",
+				"items[1].currency_code", "items[2]",
+			},
+		},
+		8: {
+			req: badContacts,
+			wantPaths: []string{
+				"pickup.contact.first_name", "pickup.contact.email",
+				"pickup.special_instructions",
+				"dropoff.location.latitude", "dropoff.location.longitude",
+				"dropoff.contact.email",
+			},
+		},
+		9: {
+			req: &uber.DeliveryRequest{
+				Items:   []*uber.Item{{Title: "Shoes", Quantity: 1}},
+				Pickup:  &uber.Endpoint{},
+				Dropoff: &uber.Endpoint{Location: &uber.Location{City: "New York"}, Contact: &uber.Contact{FirstName: "Calvin"}},
+			},
+			wantPaths: []string{"pickup.location", "pickup.contact", "dropoff.location.address"},
+		},
+		10: {req: noDropoff, wantPaths: []string{"dropoff"}, wantErrIs: uber.ErrInvalidRequest},
+	}
+
+	for i, tt := range tests {
+		err := tt.req.Validate()
+		if len(tt.wantPaths) == 0 {
+			if err != nil {
+				t.Errorf("#%d: unexpected err: %v", i, err)
+			}
+			continue
+		}
+		if g, w := fieldPaths(err), tt.wantPaths; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: paths:\ngot:  %q\nwant: %q\nerr: %v", i, g, w, err)
+		}
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("#%d: got err=%v, want one matching %v", i, err, tt.wantErrIs)
+		}
+	}
+
+	// The nil dropoff must be reported as such and not as a nil pickup.
+	if err := noDropoff.Validate(); !strings.Contains(err.Error(), "dropoff: a non-nil dropoff is required") {
+		t.Errorf("unexpected message for a nil dropoff: %v", err)
+	}
+}
+
+func TestRideAndEstimateRequestValidate(t *testing.T) {
+	rideTests := [...]struct {
+		req       *uber.RideRequest
+		wantPaths []string
+		wantErrIs []error
+	}{
+		0: {req: &uber.RideRequest{FareID: "f1", StartPlace: uber.PlaceHome, EndLatitude: 37.77, EndLongitude: -122.41}},
+		1: {req: nil, wantPaths: []string{"fare_id"}, wantErrIs: []error{uber.ErrInvalidFareID}},
+		2: {
+			req:       &uber.RideRequest{StartPlace: "gym", EndPlace: "pub", SeatCount: 3},
+			wantPaths: []string{"fare_id", "start_place_id", "end_place_id", "seat_count"},
+			wantErrIs: []error{
+				uber.ErrInvalidFareID, uber.ErrInvalidStartPlaceOrCoords,
+				uber.ErrInvalidEndPlaceOrCoords, uber.ErrInvalidRequest,
+			},
+		},
+		3: {
+			req:       &uber.RideRequest{FareID: "f1", StartLatitude: -95, StartLongitude: 200, EndPlace: uber.PlaceWork},
+			wantPaths: []string{"start_latitude", "start_longitude"},
+		},
+	}
+
+	for i, tt := range rideTests {
+		err := tt.req.Validate()
+		if g, w := fieldPaths(err), tt.wantPaths; !reflect.DeepEqual(g, w) {
+			t.Errorf("ride #%d: paths:\ngot:  %q\nwant: %q\nerr: %v", i, g, w, err)
+		}
+		for _, target := range tt.wantErrIs {
+			if !errors.Is(err, target) {
+				t.Errorf("ride #%d: got err=%v, want one matching %v", i, err, target)
+			}
+		}
+	}
+
+	estimateTests := [...]struct {
+		req       *uber.EstimateRequest
+		wantErr   bool
+		wantPaths []string
+	}{
+		0: {req: &uber.EstimateRequest{StartLatitude: 37.77, StartLongitude: -122.41, EndLatitude: 37.78, EndLongitude: -122.51}},
+		1: {req: nil, wantErr: true},
+		2: {
+			req:       &uber.EstimateRequest{StartLatitude: 37.77, StartLongitude: -122.41, EndLongitude: 181, SeatCount: -1},
+			wantErr:   true,
+			wantPaths: []string{"end_longitude", "seat_count"},
+		},
+	}
+
+	for i, tt := range estimateTests {
+		err := tt.req.Validate()
+		gotErr := err != nil
+		if gotErr != tt.wantErr {
+			t.Errorf("estimate #%d: gotErr=(%v) wantErr=(%v) err=(%v)", i, gotErr, tt.wantErr, err)
+		}
+		if g, w := fieldPaths(err), tt.wantPaths; !reflect.DeepEqual(g, w) {
+			t.Errorf("estimate #%d: paths:\ngot:  %q\nwant: %q", i, g, w)
+		}
+	}
+
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+	// Invalid estimate requests must be rejected before any request is made.
+	client.SetHTTPRoundTripper(&tRoundTripper{route: "unreachable"})
+	if _, err := client.PriceEstimatesPaginator(estimateTests[2].req); !errors.Is(err, uber.ErrInvalidRequest) {
+		t.Errorf("price estimates: got err=%v, want one matching %v", err, uber.ErrInvalidRequest)
+	}
+}
+
+func TestRequestDeliveryQuote(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: deliveryRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	endpoint := func(address string) *uber.Endpoint {
+		return &uber.Endpoint{
+			Location: &uber.Location{PrimaryAddress: address, State: "NY", Country: "US"},
+			Contact:  &uber.Contact{CompanyName: "orijtech", Email: "deliveries@orijtech.com"},
+		}
+	}
+	dreq := &uber.DeliveryRequest{
+		Pickup:  endpoint("Empire State Building"),
+		Dropoff: endpoint("530 W 113th Street"),
+		Items:   []*uber.Item{{Title: "phone chargers", Quantity: 10}},
+	}
+
+	quotes, err := client.RequestDeliveryQuote(dreq)
+	if err != nil {
+		t.Fatalf("RequestDeliveryQuote: %v", err)
+	}
+	if g, w := len(quotes), 2; g != w {
+		t.Fatalf("quotes: got=%d want=%d", g, w)
+	}
+	if !quotes[0].Expired() || quotes[1].Expired() {
+		t.Errorf("expecting only the first quote to have expired")
+	}
+	if g, w := quotes[1].FeeMoney().String(), "5.00 USD"; g != w {
+		t.Errorf("fee: got=%q want=%q", g, w)
+	}
+	if g, w := float64(quotes[1].DropoffETAMinutes), 21.0; g != w {
+		t.Errorf("dropoff ETA: got=%v want=%v", g, w)
+	}
+
+	if _, err := client.RequestDeliveryQuote(&uber.DeliveryRequest{Pickup: endpoint("Empire State Building")}); err == nil {
+		t.Errorf("expecting an error without a dropoff")
+	}
+
+	errRejected := errors.New("too expensive")
+	want := deliveryResponseFromFile(deliveryResponsePath(deliveryResponseID1))
+	tests := [...]struct {
+		prompt  func(*uber.DeliveryQuote) error
+		wantErr error
+	}{
+		0: {
+			prompt: func(dq *uber.DeliveryQuote) error {
+				// The expired quote is cheaper but skipped.
+				if dq.ID != want.QuoteID {
+					return fmt.Errorf("prompted with quote %q", dq.ID)
+				}
+				return nil
+			},
+		},
+		1: {
+			prompt:  func(*uber.DeliveryQuote) error { return errRejected },
+			wantErr: errRejected,
+		},
+	}
+
+	for i, tt := range tests {
+		req := *dreq
+		req.PromptOnQuote = tt.prompt
+		delivery, err := client.RequestDelivery(&req)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("#%d: got err=(%v) want=(%v)", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if g, w := delivery.QuoteID, want.QuoteID; g != w {
+			t.Errorf("#%d: quoteID: got=%q want=%q", i, g, w)
+		}
+		if req.QuoteID != "" {
+			t.Errorf("#%d: the request of the caller was modified", i)
+		}
+	}
+
+	// Fees in different currencies cannot be compared.
+	client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, mixedQuotesRoundTripper{}))
+	req := *dreq
+	req.PromptOnQuote = func(dq *uber.DeliveryQuote) error {
+		t.Errorf("prompted with quote %q despite the mixed currencies", dq.ID)
+		return nil
+	}
+	if _, err := client.RequestDelivery(&req); err == nil {
+		t.Errorf("mixed currencies: expected a non-nil error")
+	}
+}
+
+type mixedQuotesRoundTripper struct{}
+
+var _ http.RoundTripper = mixedQuotesRoundTripper{}
+
+func (mixedQuotesRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, "/deliveries/quote") {
+		return makeResp("Not Found", http.StatusNotFound), nil
+	}
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(strings.NewReader(`{"quotes":[
+		{"quote_id":"usd","expires_at":4102444800,"fee":5.0,"currency_code":"USD"},
+		{"quote_id":"eur","expires_at":4102444800,"fee":4.5,"currency_code":"EUR"}
+	]}`))
+	return resp, nil
+}
+
+func TestListDriverPayments(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: listDriverPaymentsRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		req           *uber.DriverInfoQuery
+		wantPageCount int
+		wantItemCount int
+		wantErr       bool
+	}{
+		0: {
+			req:           nil, // No page limit, pagination all of them
+			wantPageCount: 4,
+			wantItemCount: 10,
+		},
+		1: {
+			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 1},
+			wantPageCount: 1,
+			wantItemCount: 2,
+		},
+		2: {
+			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 3},
+			wantPageCount: 3,
+			wantItemCount: 6,
+		},
+	}
+
+	for i, tt := range tests {
+		if tt.req != nil {
+			tt.req.Throttle = uber.NoThrottle
+		}
+		dres, err := client.ListDriverPayments(tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+
+		if dres == nil {
+			t.Errorf("#%d: expecting non-nil delivery response", i)
+			continue
+		}
+
+		itemCount := 0
+		pageCount := 0
+		for page := range dres.Pages {
+			if page.Err != nil {
+				t.Errorf("#%d: err: %v", i, page.Err)
+				continue
+			}
+			pageCount += 1
+			itemCount += len(page.Payments)
+		}
+		if g, w := itemCount, tt.wantItemCount; g != w {
+			t.Errorf("#%d: itemCount:: got=%d want=%d", i, g, w)
+		}
+		if g, w := pageCount, tt.wantPageCount; g != w {
+			t.Errorf("#%d: pageCount:: got=%d want=%d", i, g, w)
+		}
+	}
+}
+
+func TestListDriverTrips(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: listDriverTripsRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		req           *uber.DriverInfoQuery
+		wantPageCount int
+		wantItemCount int
+		wantErr       bool
+	}{
+		0: {
+			req:           nil, // No page limit, pagination all of them
+			wantPageCount: 4,
+			wantItemCount: 10,
+		},
+		1: {
+			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 1},
+			wantPageCount: 1,
+			wantItemCount: 2,
+		},
+		2: {
+			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 3},
+			wantPageCount: 3,
+			wantItemCount: 6,
+		},
+	}
+
+	for i, tt := range tests {
+		if tt.req != nil {
+			tt.req.Throttle = uber.NoThrottle
+		}
+		dres, err := client.ListDriverTrips(tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+
+		if dres == nil {
+			t.Errorf("#%d: expecting non-nil delivery response", i)
+			continue
+		}
+
+		itemCount := 0
+		pageCount := 0
+		for page := range dres.Pages {
+			if page.Err != nil {
+				t.Errorf("#%d: err: %v", i, page.Err)
+				continue
+			}
+			pageCount += 1
+			itemCount += len(page.Trips)
+		}
+		if g, w := itemCount, tt.wantItemCount; g != w {
+			t.Errorf("#%d: itemCount:: got=%d want=%d", i, g, w)
+		}
+		if g, w := pageCount, tt.wantPageCount; g != w {
+			t.Errorf("#%d: pageCount:: got=%d want=%d", i, g, w)
+		}
+	}
+}
+
+func TestPaginator(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: listDriverTripsRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		req           *uber.DriverInfoQuery
+		wantOffsets   []int64
+		wantItemCount int
+	}{
+		0: {
+			req:           &uber.DriverInfoQuery{},
+			wantOffsets:   []int64{0, 2, 4, 6},
+			wantItemCount: 10,
+		},
+		1: {
+			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 3},
+			wantOffsets:   []int64{0, 2, 4},
+			wantItemCount: 6,
+		},
+	}
+
+	ctx := context.Background()
+	for i, tt := range tests {
+		tt.req.Throttle = uber.NoThrottle
+
+		var offsets []int64
+		itemCount := 0
+		for page, err := range driverTripsPaginator(t, client, tt.req).Pages(ctx) {
+			if err != nil {
+				t.Fatalf("#%d: unexpected err: %v", i, err)
+			}
+			if g, w := page.PageNumber, len(offsets); g != w {
+				t.Errorf("#%d: pageNumber: got=%d want=%d", i, g, w)
+			}
+			offsets = append(offsets, page.Offset)
+			itemCount += len(page.Items)
+		}
+		if !reflect.DeepEqual(offsets, tt.wantOffsets) {
+			t.Errorf("#%d: offsets:: got=%v want=%v", i, offsets, tt.wantOffsets)
+		}
+		if g, w := itemCount, tt.wantItemCount; g != w {
+			t.Errorf("#%d: itemCount:: got=%d want=%d", i, g, w)
+		}
+
+		allCount := 0
+		for trip, err := range driverTripsPaginator(t, client, tt.req).All(ctx) {
+			if err != nil {
+				t.Fatalf("#%d: All: unexpected err: %v", i, err)
+			}
+			if trip == nil {
+				t.Errorf("#%d: All: unexpected nil trip", i)
+			}
+			allCount += 1
+		}
+		if g, w := allCount, tt.wantItemCount; g != w {
+			t.Errorf("#%d: All: itemCount:: got=%d want=%d", i, g, w)
+		}
+	}
+
+	// Next keeps on reporting the end of paging.
+	pgr := driverTripsPaginator(t, client, &uber.DriverInfoQuery{Throttle: uber.NoThrottle, MaxPageNumber: 1})
+	if _, err := pgr.Next(ctx); err != nil {
+		t.Fatalf("Next: unexpected err: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := pgr.Next(ctx); !errors.Is(err, uber.ErrNoMorePages) {
+			t.Errorf("Next #%d: got err=(%v) want ErrNoMorePages", i, err)
+		}
+	}
+
+	// Stopping a range loop early must not retrieve any more pages.
+	counter := &countingRoundTripper{next: backend}
+	client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, counter))
+	for range driverTripsPaginator(t, client, &uber.DriverInfoQuery{Throttle: uber.NoThrottle}).All(ctx) {
+		break
+	}
+	if g, w := counter.count(), 1; g != w {
+		t.Errorf("requests after an early break: got=%d want=%d", g, w)
+	}
+
+	// Consumers that stop reading must not leak the paging goroutine.
+	dres, err := client.ListDriverTrips(&uber.DriverInfoQuery{Throttle: uber.NoThrottle})
+	if err != nil {
+		t.Fatalf("ListDriverTrips: unexpected err: %v", err)
+	}
+	<-dres.Pages
+	dres.Cancel()
+	select {
+	case <-drain(dres.Pages):
+	case <-time.After(2 * time.Second):
+		t.Errorf("pages channel was not closed after Cancel")
+	}
+}
+
+// drain discards the pages sent on pagesChan and
+// reports on the returned channel once it is closed.
+func drain[T any](pagesChan <-chan T) <-chan bool {
+	done := make(chan bool)
+	go func() {
+		for range pagesChan {
+		}
+		close(done)
+	}()
+	return done
+}
+
+type countingRoundTripper struct {
+	mu   sync.Mutex
+	hits int
+	next http.RoundTripper
+}
+
+func (crt *countingRoundTripper) count() int {
+	crt.mu.Lock()
+	defer crt.mu.Unlock()
+	return crt.hits
+}
+
+func (crt *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	crt.mu.Lock()
+	crt.hits += 1
+	crt.mu.Unlock()
+	return crt.next.RoundTrip(req)
+}
+
+func TestCollect(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: listDriverPaymentsRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		opts          *uber.CollectOptions
+		failAtOffset  string
+		wantItemCount int
+		wantErrPage   int
+		wantErr       bool
+	}{
+		0: {opts: nil, wantItemCount: 10},
+		1: {opts: &uber.CollectOptions{MaxItems: 3}, wantItemCount: 3},
+		2: {opts: &uber.CollectOptions{Since: time.Unix(1502842800, 0)}, wantItemCount: 6},
+		3: {
+			opts: &uber.CollectOptions{
+				Since: time.Unix(1502842800, 0),
+				Until: time.Unix(1502862000, 0),
+			},
+			wantItemCount: 4,
+		},
+		4: {
+			opts:          &uber.CollectOptions{Since: time.Unix(1502842800, 0), MaxItems: 5},
+			wantItemCount: 5,
+		},
+
+		// The items of the pages retrieved before the failure are returned.
+		5: {failAtOffset: "4", wantItemCount: 4, wantErrPage: 2, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var rt http.RoundTripper = backend
+		if tt.failAtOffset != "" {
+			rt = &failAtOffsetRoundTripper{offset: tt.failAtOffset, next: backend}
+		}
+		client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, rt))
+
+		dpq := &uber.DriverInfoQuery{Throttle: uber.NoThrottle}
+		payments, err := client.AllDriverPayments(context.Background(), dpq, tt.opts)
+		if g, w := len(payments), tt.wantItemCount; g != w {
+			t.Errorf("#%d: itemCount:: got=%d want=%d", i, g, w)
+		}
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("#%d: unexpected err: %v", i, err)
+			}
+			continue
+		}
+
+		pre := new(uber.PartialResultError)
+		if !errors.As(err, &pre) {
+			t.Errorf("#%d: got err=(%v) want a *PartialResultError", i, err)
+			continue
+		}
+		if g, w := pre.PageNumber, tt.wantErrPage; g != w {
+			t.Errorf("#%d: failed pageNumber:: got=%d want=%d", i, g, w)
+		}
+		if g, w := strconv.FormatInt(pre.Offset, 10), tt.failAtOffset; g != w {
+			t.Errorf("#%d: failed offset:: got=%s want=%s", i, g, w)
+		}
+	}
+}
+
+func TestCollectSkipsNilItems(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	// Null elements decode to nil trips.
+	page := `{"count":3,"limit":50,"offset":0,"trips":[null,{"trip_id":"t1","start_time":1502842800},null]}`
+	tests := [...]struct {
+		opts *uber.CollectOptions
+	}{
+		0: {opts: nil},
+		1: {opts: &uber.CollectOptions{Since: time.Unix(1502842000, 0)}},
+	}
+
+	for i, tt := range tests {
+		backend := &tripSequenceRoundTripper{snapshots: []string{page}}
+		client.SetHTTPRoundTripper(uberOAuth2.TransportWithBase(testOAuth2Token1, backend))
+
+		dpq := &uber.DriverInfoQuery{Throttle: uber.NoThrottle}
+		trips, err := client.AllDriverTrips(context.Background(), dpq, tt.opts)
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if len(trips) != 1 || trips[0] == nil || trips[0].TripID != "t1" {
+			t.Errorf("#%d: got trips=%v, want only trip t1", i, trips)
+		}
+	}
+}
+
+// failAtOffsetRoundTripper fails the requests for the page at offset.
+type failAtOffsetRoundTripper struct {
+	offset string
+	next   http.RoundTripper
+}
+
+func (frt *failAtOffsetRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("offset") == frt.offset {
+		return makeResp("backend unavailable", http.StatusServiceUnavailable), nil
+	}
+	return frt.next.RoundTrip(req)
+}
+
+func TestResumePaging(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: listDriverPaymentsRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	dres, err := client.ListDriverPayments(&uber.DriverInfoQuery{
+		Throttle:      uber.NoThrottle,
+		LimitPerPage:  2,
+		MaxPageNumber: 2,
+	})
+	if err != nil {
+		t.Fatalf("ListDriverPayments: unexpected err: %v", err)
+	}
+	var lastPage *uber.DriverInfoPage
+	itemCount := 0
+	for page := range dres.Pages {
+		if page.Err != nil {
+			t.Fatalf("page #%d: err: %v", page.PageNumber, page.Err)
+		}
+		itemCount += len(page.Payments)
+		lastPage = page
+	}
+	if itemCount != 4 {
+		t.Fatalf("itemCount: got=%d want=4", itemCount)
+	}
+
+	// The cursor must survive a round trip through storage.
+	blob, err := json.Marshal(lastPage)
+	if err != nil {
+		t.Fatalf("serializing the last page: %v", err)
+	}
+	saved := new(uber.DriverInfoPage)
+	if err := json.Unmarshal(blob, saved); err != nil {
+		t.Fatalf("deserializing the last page: %v", err)
+	}
+	if saved.NextCursor == "" {
+		t.Fatal("expecting a non-blank cursor to resume from")
+	}
+
+	resumed, err := client.DriverPaymentsPaginator(&uber.DriverInfoQuery{
+		Throttle:     uber.NoThrottle,
+		LimitPerPage: 2,
+		Cursor:       saved.NextCursor,
+	})
+	if err != nil {
+		t.Fatalf("resuming paging: unexpected err: %v", err)
+	}
+	var offsets []int64
+	for page, err := range resumed.Pages(context.Background()) {
+		if err != nil {
+			t.Fatalf("resumed paging: unexpected err: %v", err)
+		}
+		offsets = append(offsets, page.Offset)
+		itemCount += len(page.Items)
+	}
+	if want := []int64{4, 6}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("resumed offsets: got=%v want=%v", offsets, want)
+	}
+	if itemCount != 10 {
+		t.Errorf("total itemCount: got=%d want=10", itemCount)
+	}
+	if g := resumed.Cursor(); g != "" {
+		t.Errorf("got cursor=%q once paging is over, want a blank one", g)
+	}
+
+	badCursors := [...]struct {
+		cursor  uber.Cursor
+		wantErr error
+	}{
+		0: {cursor: "not a cursor!", wantErr: uber.ErrInvalidCursor},
+		1: {cursor: saved.NextCursor, wantErr: uber.ErrCursorMismatch},
+	}
+	for i, tt := range badCursors {
+		// Driver trips cannot be resumed from a driver payments cursor.
+		p := driverTripsPaginator(t, client, &uber.DriverInfoQuery{Cursor: tt.cursor})
+		if _, err := p.Next(context.Background()); !errors.Is(err, tt.wantErr) {
+			t.Errorf("#%d: got err=(%v) want=(%v)", i, err, tt.wantErr)
+		}
+	}
+}
+
+func driverTripsPaginator(t *testing.T, client *uber.Client, dpq *uber.DriverInfoQuery) *uber.Paginator[*uber.Trip] {
+	t.Helper()
+	p, err := client.DriverTripsPaginator(dpq)
+	if err != nil {
+		t.Fatalf("DriverTripsPaginator: unexpected err: %v", err)
+	}
+	return p
+}
+
+// queryRecordingRoundTripper records the queries of the
+// requests that it serves with an empty page of deliveries.
+type queryRecordingRoundTripper struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+var _ http.RoundTripper = (*queryRecordingRoundTripper)(nil)
+
+func (qrt *queryRecordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	qrt.mu.Lock()
+	qrt.queries = append(qrt.queries, req.URL.RawQuery)
+	qrt.mu.Unlock()
+
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(strings.NewReader(`{"count":0,"deliveries":[]}`))
+	return resp, nil
+}
+
+func TestDeliveriesPaginatorDefaultStatus(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	tests := [...]struct {
+		dReq       *uber.DeliveryListRequest
+		wantStatus string
+	}{
+		// Without a request, only deliveries whose receipt is ready are listed.
+		0: {dReq: nil, wantStatus: "ready"},
+		1: {dReq: &uber.DeliveryListRequest{Status: uber.DeliveryStatusCompleted}, wantStatus: "completed"},
+		2: {dReq: &uber.DeliveryListRequest{}, wantStatus: ""},
+	}
+
+	for i, tt := range tests {
+		backend := new(queryRecordingRoundTripper)
+		client.SetHTTPRoundTripper(backend)
+		p, err := client.DeliveriesPaginator(tt.dReq)
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if _, err := p.Next(context.Background()); err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if len(backend.queries) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(backend.queries))
+			continue
+		}
+		query, err := url.ParseQuery(backend.queries[0])
+		if err != nil {
+			t.Errorf("#%d: parsing query: %v", i, err)
+			continue
+		}
+		if g, w := query.Get("status"), tt.wantStatus; g != w {
+			t.Errorf("#%d: status: got=%q want=%q", i, g, w)
+		}
+	}
+}
+
+func TestListDeliveries(t *testing.T) {
+	t.Skipf("Need to get ListDelivery samples from Uber")
+
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: listDeliveriesRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
+
+	tests := [...]struct {
+		req         *uber.DeliveryListRequest
+		wantAtLeast int
+		wantErr     bool
+	}{
+		0: {
+			req: &uber.DeliveryListRequest{},
+		},
+		1: {
+			req: nil,
+		},
+		2: {
+			req: &uber.DeliveryListRequest{
+				LimitPerPage:  4,
+				MaxPageNumber: 2,
+				StartOffset:   10,
+			},
+			wantAtLeast: 8,
+		},
+	}
+
+	for i, tt := range tests {
+		dres, err := client.ListDeliveries(tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+
+		if dres == nil {
+			t.Errorf("#%d: expecting non-nil delivery response", i)
+			continue
+		}
+
+		itemCount := 0
+		for page := range dres.Pages {
+			if page.Err != nil {
+				t.Errorf("#%d: err: %v", i, page.Err)
+				continue
+			}
+
+			itemCount += len(page.Deliveries)
+		}
+		if itemCount < tt.wantAtLeast {
+			t.Errorf("#%d: got=%d wantAtLeast=%d", i, itemCount, tt.wantAtLeast)
+		}
+	}
+}
+
+type sandboxState string
+
+const (
+	sandboxUnknown    sandboxState = "unknown"
+	sandboxSandbox    sandboxState = "sandboxed"
+	sandboxProduction sandboxState = "production"
+)
+
+func TestClientSandboxing(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	backend := &tRoundTripper{route: sandboxTesterRoute}
+	client.SetHTTPRoundTripper(backend)
+
+	tests := [...]struct {
+		do func(c *uber.Client)
+
+		sandboxed bool
+		want      sandboxState
+	}{
+
+		0: {
+			want:      sandboxProduction,
+			sandboxed: false,
+
+			do: func(c *uber.Client) {
+				resChan, _, _ := c.EstimatePrice(&uber.EstimateRequest{
+					StartLatitude:  37.7752315,
+					EndLatitude:    37.7752415,
+					StartLongitude: -122.418075,
+					EndLongitude:   -122.518075,
+				})
+				for res := range resChan {
+					if false {
+						t.Logf("res: %#v\n", res)
+					}
+				}
+			},
+		},
+		1: {
+			want:      sandboxSandbox,
+			sandboxed: true,
+
+			do: func(c *uber.Client) {
+				_, _ = c.RequestRide(&uber.RideRequest{
+					FareID:     "fareID-1",
+					StartPlace: uber.PlaceHome,
+					EndPlace:   uber.PlaceWork,
+				})
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		client.SetSandboxMode(tt.sandboxed)
+		tt.do(client)
+
+		got := backend.exhaust.(sandboxState)
+		if want := tt.want; got != want {
+			t.Errorf("#%d: sandboxed: got=(%v) want=(%v)", i, got, want)
+		}
+	}
+}
+
+func TestListHistory(t *testing.T) {
+	t.Skipf("Needs quite detailed data and intricate tests with paging")
+
+	// client, err := uber.NewClient(testToken1)
+	// if err != nil {
+	// 	t.Fatalf("initializing client; %v", err)
+	// }
+
+	// if err != nil {
+	// 	t.Fatalf("initializing client; %v", err)
+	// }
+
+	// testingRoundTripper := &tRoundTripper{route: listHistory}
+	// client.SetHTTPRoundTripper(testingRoundTripper)
+
+	// tests := [...]struct{}{}
+}
+
+func TestEstimatePrice(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	testingRoundTripper := &tRoundTripper{route: estimatePriceRoute}
+	client.SetHTTPRoundTripper(testingRoundTripper)
+
+	tests := [...]struct {
+		ereq    *uber.EstimateRequest
+		wantErr bool
+		want    []*uber.PriceEstimate
+	}{
+		0: {
+			ereq: &uber.EstimateRequest{
+				StartLatitude:  37.7752315,
+				EndLatitude:    37.7752415,
+				StartLongitude: -122.418075,
+				EndLongitude:   -122.518075,
+			},
+			want: priceEstimateFromFile("./testdata/estimate-1.json"),
+		},
+		1: {
+			ereq:    nil,
+			wantErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		estimatesChan, cancelPaging, err := client.EstimatePrice(tt.ereq)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d expecting a non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d err: %v", i, err)
+			continue
+		}
+
+		firstPage := <-estimatesChan
+		// Then cancel it
+		cancelPaging()
+
+		if err := firstPage.Err; err != nil {
+			t.Errorf("#%d paging err: %v, firstPage: %#v", i, err, firstPage)
+			continue
+		}
+		estimates := firstPage.Estimates
+
+		gotBlob, wantBlob := jsonSerialize(estimates), jsonSerialize(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		}
+	}
+}
+
+func TestEstimateTime(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
+
+	testingRoundTripper := &tRoundTripper{route: estimateTimeRoute}
+	client.SetHTTPRoundTripper(testingRoundTripper)
+
+	tests := [...]struct {
+		treq    *uber.EstimateRequest
+		wantErr bool
+		want    []*uber.TimeEstimate
+	}{
+		0: {
+			treq: &uber.EstimateRequest{
+				StartLatitude:  37.7752315,
+				EndLatitude:    37.7752415,
+				StartLongitude: -122.418075,
+				EndLongitude:   -122.518075,
+				ProductID:      "a1111c8c-c720-46c3-8534-2fcdd730040d",
+			},
+			want: timeEstimateFromFile("./testdata/time-estimate-1.json"),
+		},
+		1: {
+			treq:    nil,
+			wantErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		estimatesChan, cancelPaging, err := client.EstimateTime(tt.treq)
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d: want non-nil error", i)
+				t.Errorf("#%d expecting a non-nil error", i)
 			}
 			continue
 		}
 
 		if err != nil {
-			t.Errorf("#%d: unexpected err: %v", i, err)
+			t.Errorf("#%d err: %v", i, err)
 			continue
 		}
 
-		if dres == nil {
-			t.Errorf("#%d: expecting non-nil delivery response", i)
+		firstPage := <-estimatesChan
+		// Then cancel it
+		cancelPaging()
+
+		if err := firstPage.Err; err != nil {
+			t.Errorf("#%d paging err: %v, firstPage: %#v", i, err, firstPage)
 			continue
 		}
-		gotBytes := jsonSerialize(dres)
-		wantBytes := jsonSerialize(tt.want)
-		if !bytes.Equal(gotBytes, wantBytes) {
-			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBytes, wantBytes)
+		estimates := firstPage.Estimates
+
+		gotBlob, wantBlob := jsonSerialize(estimates), jsonSerialize(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestListDriverPayments(t *testing.T) {
+func TestDriverProfile(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	backend := &tRoundTripper{route: listDriverPaymentsRoute}
-	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
-	client.SetHTTPRoundTripper(transport)
+	testingRoundTripper := &tRoundTripper{route: retrieveDriverProfileRoute}
+	client.SetHTTPRoundTripper(testingRoundTripper)
+
+	invalidToken := fmt.Sprintf("%v", time.Now().Unix())
 
 	tests := [...]struct {
-		req           *uber.DriverInfoQuery
-		wantPageCount int
-		wantItemCount int
-		wantErr       bool
+		wantErr     bool
+		bearerToken string
+		want        *uber.Profile
 	}{
 		0: {
-			req:           nil, // No page limit, pagination all of them
-			wantPageCount: 4,
-			wantItemCount: 10,
+			bearerToken: testToken1,
+			want:        driverProfileFromFileByToken(testToken1),
 		},
 		1: {
-			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 1},
-			wantPageCount: 1,
-			wantItemCount: 2,
-		},
-		2: {
-			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 3},
-			wantPageCount: 3,
-			wantItemCount: 6,
+			bearerToken: invalidToken,
+			wantErr:     true,
 		},
 	}
 
 	for i, tt := range tests {
-		if tt.req != nil {
-			tt.req.Throttle = uber.NoThrottle
-		}
-		dres, err := client.ListDriverPayments(tt.req)
+		client.SetBearerToken(tt.bearerToken)
+		prof, err := client.DriverProfile()
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d: want non-nil error", i)
+				t.Errorf("#%d expecting a non-nil error", i)
 			}
 			continue
 		}
 
 		if err != nil {
-			t.Errorf("#%d: unexpected err: %v", i, err)
-			continue
-		}
-
-		if dres == nil {
-			t.Errorf("#%d: expecting non-nil delivery response", i)
+			t.Errorf("#%d: err: %v", i, err)
 			continue
 		}
 
-		itemCount := 0
-		pageCount := 0
-		for page := range dres.Pages {
-			if page.Err != nil {
-				t.Errorf("#%d: err: %v", i, page.Err)
-				continue
-			}
-			pageCount += 1
-			itemCount += len(page.Payments)
-		}
-		if g, w := itemCount, tt.wantItemCount; g != w {
-			t.Errorf("#%d: itemCount:: got=%d want=%d", i, g, w)
-		}
-		if g, w := pageCount, tt.wantPageCount; g != w {
-			t.Errorf("#%d: pageCount:: got=%d want=%d", i, g, w)
+		gotBlob, wantBlob := jsonSerialize(prof), jsonSerialize(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestListDriverTrips(t *testing.T) {
+func TestRetrieveMyProfile(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	backend := &tRoundTripper{route: listDriverTripsRoute}
-	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
-	client.SetHTTPRoundTripper(transport)
+	testingRoundTripper := &tRoundTripper{route: retrieveProfileRoute}
+	client.SetHTTPRoundTripper(testingRoundTripper)
+
+	invalidToken := fmt.Sprintf("%v", time.Now().Unix())
 
 	tests := [...]struct {
-		req           *uber.DriverInfoQuery
-		wantPageCount int
-		wantItemCount int
-		wantErr       bool
+		wantErr     bool
+		bearerToken string
+		want        *uber.Profile
 	}{
 		0: {
-			req:           nil, // No page limit, pagination all of them
-			wantPageCount: 4,
-			wantItemCount: 10,
+			bearerToken: testToken1,
+			want:        profileFromFileByToken(testToken1),
 		},
 		1: {
-			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 1},
-			wantPageCount: 1,
-			wantItemCount: 2,
-		},
-		2: {
-			req:           &uber.DriverInfoQuery{LimitPerPage: 2, MaxPageNumber: 3},
-			wantPageCount: 3,
-			wantItemCount: 6,
+			bearerToken: invalidToken,
+			wantErr:     true,
 		},
 	}
 
 	for i, tt := range tests {
-		if tt.req != nil {
-			tt.req.Throttle = uber.NoThrottle
-		}
-		dres, err := client.ListDriverTrips(tt.req)
+		client.SetBearerToken(tt.bearerToken)
+		prof, err := client.RetrieveMyProfile()
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d: want non-nil error", i)
+				t.Errorf("#%d expecting a non-nil error", i)
 			}
 			continue
 		}
 
 		if err != nil {
-			t.Errorf("#%d: unexpected err: %v", i, err)
-			continue
-		}
-
-		if dres == nil {
-			t.Errorf("#%d: expecting non-nil delivery response", i)
+			t.Errorf("#%d: err: %v", i, err)
 			continue
 		}
 
-		itemCount := 0
-		pageCount := 0
-		for page := range dres.Pages {
-			if page.Err != nil {
-				t.Errorf("#%d: err: %v", i, page.Err)
-				continue
-			}
-			pageCount += 1
-			itemCount += len(page.Trips)
-		}
-		if g, w := itemCount, tt.wantItemCount; g != w {
-			t.Errorf("#%d: itemCount:: got=%d want=%d", i, g, w)
-		}
-		if g, w := pageCount, tt.wantPageCount; g != w {
-			t.Errorf("#%d: pageCount:: got=%d want=%d", i, g, w)
+		gotBlob, wantBlob := jsonSerialize(prof), jsonSerialize(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestListDeliveries(t *testing.T) {
-	t.Skipf("Need to get ListDelivery samples from Uber")
-
+func TestApplyPromoCode(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	backend := &tRoundTripper{route: listDeliveriesRoute}
-	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
-	client.SetHTTPRoundTripper(transport)
+	testingRoundTripper := &tRoundTripper{route: applyPromoCodeRoute}
+	client.SetHTTPRoundTripper(testingRoundTripper)
 
 	tests := [...]struct {
-		req         *uber.DeliveryListRequest
-		wantAtLeast int
-		wantErr     bool
+		wantErr   bool
+		promoCode string
+		want      *uber.PromoCode
 	}{
 		0: {
-			req: &uber.DeliveryListRequest{},
+			promoCode: promoCode1,
+			want:      promoCodeFromFileByToken(promoCode1),
 		},
 		1: {
-			req: nil,
-		},
-		2: {
-			req: &uber.DeliveryListRequest{
-				LimitPerPage:  4,
-				MaxPageNumber: 2,
-				StartOffset:   10,
-			},
-			wantAtLeast: 8,
+			// Try with a random promo code that's unauthorized.
+			promoCode: fmt.Sprintf("%v", time.Now().Unix()),
+			wantErr:   true,
 		},
 	}
 
 	for i, tt := range tests {
-		dres, err := client.ListDeliveries(tt.req)
+		appliedPromoCode, err := client.ApplyPromoCode(tt.promoCode)
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d: want non-nil error", i)
+				t.Errorf("#%d expecting a non-nil error", i)
 			}
 			continue
 		}
 
 		if err != nil {
-			t.Errorf("#%d: unexpected err: %v", i, err)
-			continue
-		}
-
-		if dres == nil {
-			t.Errorf("#%d: expecting non-nil delivery response", i)
+			t.Errorf("#%d: err: %v", i, err)
 			continue
 		}
 
-		itemCount := 0
-		for page := range dres.Pages {
-			if page.Err != nil {
-				t.Errorf("#%d: err: %v", i, page.Err)
-				continue
-			}
-
-			itemCount += len(page.Deliveries)
-		}
-		if itemCount < tt.wantAtLeast {
-			t.Errorf("#%d: got=%d wantAtLeast=%d", i, itemCount, tt.wantAtLeast)
+		gotBlob, wantBlob := jsonSerialize(appliedPromoCode), jsonSerialize(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-type sandboxState string
-
-const (
-	sandboxUnknown    sandboxState = "unknown"
-	sandboxSandbox    sandboxState = "sandboxed"
-	sandboxProduction sandboxState = "production"
-)
-
-func TestClientSandboxing(t *testing.T) {
+func TestRequestRide(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	backend := &tRoundTripper{route: sandboxTesterRoute}
-	client.SetHTTPRoundTripper(backend)
+	testingRoundTripper := &tRoundTripper{route: requestRideRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, testingRoundTripper)
+	client.SetHTTPRoundTripper(transport)
 
 	tests := [...]struct {
-		do func(c *uber.Client)
-
-		sandboxed bool
-		want      sandboxState
+		wantErr bool
+		req     *uber.RideRequest
 	}{
-
 		0: {
-			want:      sandboxProduction,
-			sandboxed: false,
-
-			do: func(c *uber.Client) {
-				resChan, _, _ := c.EstimatePrice(&uber.EstimateRequest{
-					StartLatitude:  37.7752315,
-					EndLatitude:    37.7752415,
-					StartLongitude: -122.418075,
-					EndLongitude:   -122.518075,
-				})
-				for res := range resChan {
-					if false {
-						t.Logf("res: %#v\n", res)
+			req:     nil,
+			wantErr: true,
+		},
+		1: {
+			req: &uber.RideRequest{
+				FareID:     "fareID-1",
+				StartPlace: uber.PlaceHome,
+				EndPlace:   uber.PlaceWork,
+			},
+		},
+		2: {
+			req: &uber.RideRequest{
+				StartPlace: uber.PlaceHome,
+				EndPlace:   uber.PlaceWork,
+				PromptOnFare: func(fare *uber.UpfrontFare) error {
+					if fare.Fare.Value >= 0.90 {
+						return fmt.Errorf("times are hard, not paying more than 90 cents!")
 					}
-				}
-			},
-		},
-		1: {
-			want:      sandboxSandbox,
-			sandboxed: true,
-
-			do: func(c *uber.Client) {
-				_, _ = c.RequestRide(&uber.RideRequest{
-					FareID:     "fareID-1",
-					StartPlace: uber.PlaceHome,
-					EndPlace:   uber.PlaceWork,
-				})
+					return nil
+				},
 			},
+			wantErr: true,
 		},
 	}
 
+	blankRide := new(uber.Ride)
 	for i, tt := range tests {
-		client.SetSandboxMode(tt.sandboxed)
-		tt.do(client)
+		ride, err := client.RequestRide(tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d expecting a non-nil error", i)
+			}
+			continue
+		}
 
-		got := backend.exhaust.(sandboxState)
-		if want := tt.want; got != want {
-			t.Errorf("#%d: sandboxed: got=(%v) want=(%v)", i, got, want)
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		if ride == nil {
+			t.Errorf("#%d: expecting non-nil ride", i)
+			continue
+		}
+
+		if reflect.DeepEqual(blankRide, ride) {
+			t.Errorf("#%d: expecting a non-blank ride response", i)
 		}
 	}
 }
 
-func TestListHistory(t *testing.T) {
-	t.Skipf("Needs quite detailed data and intricate tests with paging")
+func TestContextCancellation(t *testing.T) {
+	client, err := uber.NewClient(testToken1)
+	if err != nil {
+		t.Fatalf("initializing client; %v", err)
+	}
 
-	// client, err := uber.NewClient(testToken1)
-	// if err != nil {
-	// 	t.Fatalf("initializing client; %v", err)
-	// }
+	backend := &tRoundTripper{route: listDriverTripsRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, backend)
+	client.SetHTTPRoundTripper(transport)
 
-	// if err != nil {
-	// 	t.Fatalf("initializing client; %v", err)
-	// }
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	// testingRoundTripper := &tRoundTripper{route: listHistory}
-	// client.SetHTTPRoundTripper(testingRoundTripper)
+	if _, err := client.TripByIDContext(canceledCtx, ride1); !errors.Is(err, context.Canceled) {
+		t.Errorf("TripByIDContext: got err=(%v) want context.Canceled", err)
+	}
 
-	// tests := [...]struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dres, err := client.ListDriverTripsContext(ctx, &uber.DriverInfoQuery{Throttle: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("ListDriverTripsContext: unexpected err: %v", err)
+	}
+
+	pageCount := 0
+	for page := range dres.Pages {
+		if page.Err != nil {
+			t.Errorf("page #%d: err: %v", page.PageNumber, page.Err)
+			continue
+		}
+		pageCount += 1
+		// Canceling the context should stop any subsequent paging.
+		cancel()
+	}
+	if pageCount != 1 {
+		t.Errorf("pageCount: got=%d want=1", pageCount)
+	}
 }
 
-func TestEstimatePrice(t *testing.T) {
+const (
+	requestID1 = "b5512127-a134-4bf4-b1ba-fe9f48f56d9d"
+)
+
+func TestPlaceRetrieval(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	testingRoundTripper := &tRoundTripper{route: estimatePriceRoute}
+	testingRoundTripper := &tRoundTripper{route: getPlacesRoute}
 	client.SetHTTPRoundTripper(testingRoundTripper)
 
 	tests := [...]struct {
-		ereq    *uber.EstimateRequest
 		wantErr bool
-		want    []*uber.PriceEstimate
+		place   uber.PlaceName
+		want    *uber.Place
 	}{
 		0: {
-			ereq: &uber.EstimateRequest{
-				StartLatitude:  37.7752315,
-				EndLatitude:    37.7752415,
-				StartLongitude: -122.418075,
-				EndLongitude:   -122.518075,
-			},
-			want: priceEstimateFromFile("./testdata/estimate-1.json"),
+			place: "home",
+			want:  placeFromFile("685-market"),
 		},
 		1: {
-			ereq:    nil,
+			place: "work",
+			want:  placeFromFile("wallaby-way"),
+		},
+		2: {
+			place:   "workz",
+			wantErr: true,
+		},
+		3: {
+			place:   "",
 			wantErr: true,
 		},
 	}
 
 	for i, tt := range tests {
-		estimatesChan, cancelPaging, err := client.EstimatePrice(tt.ereq)
+		place, err := client.Place(tt.place)
 		if tt.wantErr {
 			if err == nil {
 				t.Errorf("#%d expecting a non-nil error", i)
@@ -711,59 +3026,63 @@ func TestEstimatePrice(t *testing.T) {
 		}
 
 		if err != nil {
-			t.Errorf("#%d err: %v", i, err)
-			continue
-		}
-
-		firstPage := <-estimatesChan
-		// Then cancel it
-		cancelPaging()
-
-		if err := firstPage.Err; err != nil {
-			t.Errorf("#%d paging err: %v, firstPage: %#v", i, err, firstPage)
+			t.Errorf("#%d: err: %v", i, err)
 			continue
 		}
-		estimates := firstPage.Estimates
 
-		gotBlob, wantBlob := jsonSerialize(estimates), jsonSerialize(tt.want)
+		gotBlob, wantBlob := jsonSerialize(place), jsonSerialize(tt.want)
 		if !bytes.Equal(gotBlob, wantBlob) {
 			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestEstimateTime(t *testing.T) {
+var testOAuth2Token1 = &oauth2.Token{
+	AccessToken:  testOAuth2AccessToken1,
+	TokenType:    "Bearer",
+	RefreshToken: "uber-test-refresh-token",
+}
+
+func TestUpfrontFare(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
-
-	testingRoundTripper := &tRoundTripper{route: estimateTimeRoute}
-	client.SetHTTPRoundTripper(testingRoundTripper)
+	testingRoundTripper := &tRoundTripper{route: upfrontFareRoute}
+	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, testingRoundTripper)
+	client.SetHTTPRoundTripper(transport)
 
 	tests := [...]struct {
-		treq    *uber.EstimateRequest
 		wantErr bool
-		want    []*uber.TimeEstimate
+		req     *uber.EstimateRequest
+		want    *uber.UpfrontFare
 	}{
 		0: {
-			treq: &uber.EstimateRequest{
-				StartLatitude:  37.7752315,
-				EndLatitude:    37.7752415,
-				StartLongitude: -122.418075,
-				EndLongitude:   -122.518075,
-				ProductID:      "a1111c8c-c720-46c3-8534-2fcdd730040d",
+			req: &uber.EstimateRequest{
+				StartPlace: uber.PlaceHome,
+
+				EndLatitude:  37.7752415,
+				EndLongitude: -122.518075,
 			},
-			want: timeEstimateFromFile("./testdata/time-estimate-1.json"),
+			want: upfrontFareFromFileByID("surge"),
 		},
 		1: {
-			treq:    nil,
+			req: &uber.EstimateRequest{
+				StartLatitude:  37.7752415,
+				StartLongitude: -122.518075,
+
+				EndPlace: uber.PlaceWork,
+			},
+			want: upfrontFareFromFileByID("no-surge"),
+		},
+		2: {
+			req:     nil,
 			wantErr: true,
 		},
 	}
 
 	for i, tt := range tests {
-		estimatesChan, cancelPaging, err := client.EstimateTime(tt.treq)
+		upfrontFare, err := client.UpfrontFare(tt.req)
 		if tt.wantErr {
 			if err == nil {
 				t.Errorf("#%d expecting a non-nil error", i)
@@ -772,56 +3091,70 @@ func TestEstimateTime(t *testing.T) {
 		}
 
 		if err != nil {
-			t.Errorf("#%d err: %v", i, err)
+			t.Errorf("#%d: err: %v", i, err)
 			continue
 		}
 
-		firstPage := <-estimatesChan
-		// Then cancel it
-		cancelPaging()
-
-		if err := firstPage.Err; err != nil {
-			t.Errorf("#%d paging err: %v, firstPage: %#v", i, err, firstPage)
+		if tt.want == nil {
+			t.Errorf("#%d: inconsistency want is nil", i)
 			continue
 		}
-		estimates := firstPage.Estimates
 
-		gotBlob, wantBlob := jsonSerialize(estimates), jsonSerialize(tt.want)
+		gotBlob, wantBlob := jsonSerialize(upfrontFare), jsonSerialize(tt.want)
 		if !bytes.Equal(gotBlob, wantBlob) {
 			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestDriverProfile(t *testing.T) {
+func TestPlaceUpdate(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	testingRoundTripper := &tRoundTripper{route: retrieveDriverProfileRoute}
+	testingRoundTripper := &tRoundTripper{route: updatePlacesRoute}
 	client.SetHTTPRoundTripper(testingRoundTripper)
 
-	invalidToken := fmt.Sprintf("%v", time.Now().Unix())
-
 	tests := [...]struct {
-		wantErr     bool
-		bearerToken string
-		want        *uber.Profile
+		wantErr bool
+		params  *uber.PlaceParams
+		want    *uber.Place
 	}{
 		0: {
-			bearerToken: testToken1,
-			want:        driverProfileFromFileByToken(testToken1),
+			params: &uber.PlaceParams{Place: uber.PlaceHome, Address: "P Sherman 42 Wallaby Way Sydney"},
+			want:   placeFromFile("wallaby-way"),
 		},
 		1: {
-			bearerToken: invalidToken,
-			wantErr:     true,
+			params: &uber.PlaceParams{Place: uber.PlaceWork, Address: "685 Market St, San Francisco, CA 94103, USA"},
+			want:   placeFromFile("685-market"),
+		},
+		2: {
+			params:  &uber.PlaceParams{},
+			wantErr: true,
+		},
+
+		3: {
+			// No place was specified.
+			params:  &uber.PlaceParams{Address: "685 Market St, San Francisco, CA 94103, USA"},
+			wantErr: true,
+		},
+
+		4: {
+			// No address was specified.
+			params:  &uber.PlaceParams{Place: uber.PlaceHome},
+			wantErr: true,
+		},
+
+		5: {
+			// No address was specified.
+			params:  &uber.PlaceParams{Place: uber.PlaceWork},
+			wantErr: true,
 		},
 	}
 
 	for i, tt := range tests {
-		client.SetBearerToken(tt.bearerToken)
-		prof, err := client.DriverProfile()
+		place, err := client.UpdatePlace(tt.params)
 		if tt.wantErr {
 			if err == nil {
 				t.Errorf("#%d expecting a non-nil error", i)
@@ -834,42 +3167,49 @@ func TestDriverProfile(t *testing.T) {
 			continue
 		}
 
-		gotBlob, wantBlob := jsonSerialize(prof), jsonSerialize(tt.want)
+		gotBlob, wantBlob := jsonSerialize(place), jsonSerialize(tt.want)
 		if !bytes.Equal(gotBlob, wantBlob) {
 			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestRetrieveMyProfile(t *testing.T) {
+func mapFromFile(tripID string) *uber.Map {
+	diskPath := mapPathFromRequestID(tripID)
+	save := new(uber.Map)
+	if err := readFromFileAndDeserialize(diskPath, save); err != nil {
+		return nil
+	}
+	return save
+}
+
+func TestRequestMap(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	testingRoundTripper := &tRoundTripper{route: retrieveProfileRoute}
+	testingRoundTripper := &tRoundTripper{route: getMapRoute}
 	client.SetHTTPRoundTripper(testingRoundTripper)
 
-	invalidToken := fmt.Sprintf("%v", time.Now().Unix())
-
 	tests := [...]struct {
-		wantErr     bool
-		bearerToken string
-		want        *uber.Profile
+		wantErr   bool
+		requestID string
+		want      *uber.Map
 	}{
 		0: {
-			bearerToken: testToken1,
-			want:        profileFromFileByToken(testToken1),
+			requestID: requestID1,
+			want:      mapFromFile(requestID1),
 		},
 		1: {
-			bearerToken: invalidToken,
-			wantErr:     true,
+			// Try with a random requestID.
+			requestID: fmt.Sprintf("%v", time.Now().Unix()),
+			wantErr:   true,
 		},
 	}
 
 	for i, tt := range tests {
-		client.SetBearerToken(tt.bearerToken)
-		prof, err := client.RetrieveMyProfile()
+		mapInfo, err := client.RequestMap(tt.requestID)
 		if tt.wantErr {
 			if err == nil {
 				t.Errorf("#%d expecting a non-nil error", i)
@@ -882,40 +3222,40 @@ func TestRetrieveMyProfile(t *testing.T) {
 			continue
 		}
 
-		gotBlob, wantBlob := jsonSerialize(prof), jsonSerialize(tt.want)
+		gotBlob, wantBlob := jsonSerialize(mapInfo), jsonSerialize(tt.want)
 		if !bytes.Equal(gotBlob, wantBlob) {
 			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestApplyPromoCode(t *testing.T) {
+func TestRequestReceipt(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
 
-	testingRoundTripper := &tRoundTripper{route: applyPromoCodeRoute}
+	testingRoundTripper := &tRoundTripper{route: requestReceiptRoute}
 	client.SetHTTPRoundTripper(testingRoundTripper)
 
 	tests := [...]struct {
 		wantErr   bool
-		promoCode string
-		want      *uber.PromoCode
+		requestID string
+		want      *uber.Receipt
 	}{
 		0: {
-			promoCode: promoCode1,
-			want:      promoCodeFromFileByToken(promoCode1),
+			requestID: requestID1,
+			want:      receiptFromFile(requestID1),
 		},
 		1: {
-			// Try with a random promo code that's unauthorized.
-			promoCode: fmt.Sprintf("%v", time.Now().Unix()),
+			// Try with a random requestID.
+			requestID: fmt.Sprintf("%v", time.Now().Unix()),
 			wantErr:   true,
 		},
 	}
 
 	for i, tt := range tests {
-		appliedPromoCode, err := client.ApplyPromoCode(tt.promoCode)
+		receipt, err := client.RequestReceipt(tt.requestID)
 		if tt.wantErr {
 			if err == nil {
 				t.Errorf("#%d expecting a non-nil error", i)
@@ -928,370 +3268,848 @@ func TestApplyPromoCode(t *testing.T) {
 			continue
 		}
 
-		gotBlob, wantBlob := jsonSerialize(appliedPromoCode), jsonSerialize(tt.want)
+		gotBlob, wantBlob := jsonSerialize(receipt), jsonSerialize(tt.want)
 		if !bytes.Equal(gotBlob, wantBlob) {
 			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
 		}
 	}
 }
 
-func TestRequestRide(t *testing.T) {
-	client, err := uber.NewClient(testToken1)
-	if err != nil {
-		t.Fatalf("initializing client; %v", err)
-	}
-
-	testingRoundTripper := &tRoundTripper{route: requestRideRoute}
-	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, testingRoundTripper)
-	client.SetHTTPRoundTripper(transport)
-
+func TestDecimal(t *testing.T) {
 	tests := [...]struct {
+		in      string
+		want    string
 		wantErr bool
-		req     *uber.RideRequest
 	}{
-		0: {
-			req:     nil,
-			wantErr: true,
-		},
-		1: {
-			req: &uber.RideRequest{
-				FareID:     "fareID-1",
-				StartPlace: uber.PlaceHome,
-				EndPlace:   uber.PlaceWork,
-			},
-		},
-		2: {
-			req: &uber.RideRequest{
-				StartPlace: uber.PlaceHome,
-				EndPlace:   uber.PlaceWork,
-				PromptOnFare: func(fare *uber.UpfrontFare) error {
-					if fare.Fare.Value >= 0.90 {
-						return fmt.Errorf("times are hard, not paying more than 90 cents!")
-					}
-					return nil
-				},
-			},
-			wantErr: true,
-		},
+		0: {in: "12.5", want: "12.5"},
+		1: {in: "-0.125", want: "-0.125"},
+		2: {in: "7", want: "7"},
+		3: {in: "1.5e2", want: "150"},
+		4: {in: "1.25e-1", want: "0.125"},
+		5: {in: "1/3", wantErr: true},
+		6: {in: "", wantErr: true},
+		7: {in: "$5", wantErr: true},
 	}
 
-	blankRide := new(uber.Ride)
 	for i, tt := range tests {
-		ride, err := client.RequestRide(tt.req)
+		got, err := uber.ParseDecimal(tt.in)
 		if tt.wantErr {
-			if err == nil {
-				t.Errorf("#%d expecting a non-nil error", i)
+			if !errors.Is(err, uber.ErrInvalidDecimal) {
+				t.Errorf("#%d: got err=(%v) want ErrInvalidDecimal", i, err)
 			}
 			continue
 		}
-
 		if err != nil {
-			t.Errorf("#%d: err: %v", i, err)
+			t.Errorf("#%d: unexpected err: %v", i, err)
 			continue
 		}
+		if g := got.String(); g != tt.want {
+			t.Errorf("#%d: got=%s want=%s", i, g, tt.want)
+		}
+	}
 
-		if ride == nil {
-			t.Errorf("#%d: expecting non-nil ride", i)
+	// Adding up floats accumulates errors that decimals don't.
+	sum := uber.Decimal{}
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(uber.DecimalFromFloat(0.1))
+	}
+	if g, w := sum.String(), "1.0"; g != w {
+		t.Errorf("sum: got=%s want=%s", g, w)
+	}
+	if sum.Cmp(uber.DecimalFromInt(1)) != 0 {
+		t.Errorf("sum: got=%s want it equal to 1", sum)
+	}
+	if g, w := uber.DecimalFromFloat(2.675).Round(2).String(), "2.68"; g != w {
+		t.Errorf("round: got=%s want=%s", g, w)
+	}
+
+	var decoded struct {
+		Number uber.Decimal `json:"number"`
+		String uber.Decimal `json:"string"`
+		Null   uber.Decimal `json:"null"`
+	}
+	blob := []byte(`{"number": 13.12, "string": "0.89", "null": null}`)
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatalf("unmarshaling decimals: %v", err)
+	}
+	if g, w := decoded.Number.Add(decoded.String).Add(decoded.Null).String(), "14.01"; g != w {
+		t.Errorf("decoded sum: got=%s want=%s", g, w)
+	}
+	if g, w := string(jsonSerialize(decoded.Number)), "13.12"; g != w {
+		t.Errorf("encoded: got=%s want=%s", g, w)
+	}
+}
+
+func TestMoney(t *testing.T) {
+	receipt := &uber.Receipt{Subtotal: "$12.78", TotalCharged: "$5.92", CurrencyCode: "USD"}
+	charged, err := receipt.TotalChargedMoney()
+	if err != nil {
+		t.Fatalf("TotalChargedMoney: %v", err)
+	}
+	subtotal, err := receipt.SubtotalMoney()
+	if err != nil {
+		t.Fatalf("SubtotalMoney: %v", err)
+	}
+	if g, w := charged.String(), "5.92 USD"; g != w {
+		t.Errorf("charged: got=%q want=%q", g, w)
+	}
+	if cmp, err := charged.Cmp(subtotal); err != nil || cmp >= 0 {
+		t.Errorf("charged vs subtotal: got cmp=%d err=%v want -1 and nil", cmp, err)
+	}
+	diff, err := subtotal.Sub(charged)
+	if err != nil {
+		t.Fatalf("Sub: %v", err)
+	}
+	if g, w := diff.Amount.String(), "6.86"; g != w {
+		t.Errorf("difference: got=%s want=%s", g, w)
+	}
+
+	fare := &uber.Fare{Value: 5.92, CurrencyCode: "usd"}
+	if cmp, err := fare.Money().Cmp(charged); err != nil || cmp != 0 {
+		t.Errorf("fare vs charged: got cmp=%d err=%v want 0 and nil", cmp, err)
+	}
+	euros := uber.NewMoney(uber.DecimalFromFloat(1234.5), "EUR")
+	if _, err := euros.Add(charged); !errors.Is(err, uber.ErrCurrencyMismatch) {
+		t.Errorf("adding different currencies: got err=(%v) want ErrCurrencyMismatch", err)
+	}
+
+	formats := [...]struct {
+		money  uber.Money
+		locale string
+		want   string
+	}{
+		0: {money: euros, locale: "fr-FR", want: "1\u202f234,50\u00a0€"},
+		1: {money: euros, locale: "de", want: "1.234,50\u00a0€"},
+		2: {money: euros, locale: "en_GB", want: "€1,234.50"},
+		3: {money: uber.NewMoney(uber.DecimalFromFloat(-1234567.891), "USD"), locale: "en-US", want: "-$1,234,567.89"},
+		4: {money: uber.NewMoney(uber.DecimalFromInt(1500), "JPY"), locale: "ja", want: "¥1,500"},
+		5: {money: uber.NewMoney(uber.DecimalFromFloat(3.5), "XYZ"), locale: "xx", want: "XYZ3.50"},
+	}
+	for i, tt := range formats {
+		if g := tt.money.Format(tt.locale); g != tt.want {
+			t.Errorf("#%d: got=%q want=%q", i, g, tt.want)
+		}
+		// Formatted amounts must parse back with the same locale.
+		parsed, err := uber.ParseMoneyLocale(tt.want, tt.money.CurrencyCode, tt.locale)
+		if err != nil {
+			t.Errorf("#%d: parsing %q: %v", i, tt.want, err)
 			continue
 		}
+		if parsed.Amount.Cmp(tt.money.Amount.Round(2)) != 0 {
+			t.Errorf("#%d: parsed %q as %v, want %v", i, tt.want, parsed, tt.money)
+		}
+	}
 
-		if reflect.DeepEqual(blankRide, ride) {
-			t.Errorf("#%d: expecting a non-blank ride response", i)
+	parses := [...]struct {
+		display string
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		0: {display: "CA$1,200", locale: "en", want: "1200.00 USD"},
+		1: {display: "-$12.78", locale: "en-US", want: "-12.78 USD"},
+		2: {display: "1.234,50\u00a0€", locale: "de", want: "1234.50 USD"},
+		3: {display: "1.234", locale: "de", want: "1234.00 USD"},
+		4: {display: "1,234", locale: "fr", want: "1.234 USD"},
+		// Separators of another locale are ambiguous.
+		5: {display: "1.234,50\u00a0€", locale: "en", wantErr: true},
+		6: {display: "1.2345", locale: "de", wantErr: true},
+		7: {display: "12,34,5", locale: "en", wantErr: true},
+		8: {display: "$", locale: "en", wantErr: true},
+		9: {display: "$1 234", locale: "en", wantErr: true},
+	}
+	for i, tt := range parses {
+		got, err := uber.ParseMoneyLocale(tt.display, "USD", tt.locale)
+		if tt.wantErr {
+			if !errors.Is(err, uber.ErrInvalidDecimal) {
+				t.Errorf("parse #%d: got err=(%v) want ErrInvalidDecimal", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parse #%d: unexpected err: %v", i, err)
+			continue
+		}
+		if g := got.String(); g != tt.want {
+			t.Errorf("parse #%d: got=%q want=%q", i, g, tt.want)
 		}
 	}
 }
 
-const (
-	requestID1 = "b5512127-a134-4bf4-b1ba-fe9f48f56d9d"
-)
+func TestTripAndReceiptUnits(t *testing.T) {
+	page := new(struct {
+		Trips []*uber.Trip `json:"trips"`
+	})
+	if err := readFromFileAndDeserialize(driverTripsListResponsePath(0), page); err != nil {
+		t.Fatalf("reading driver trips: %v", err)
+	}
+	trip := page.Trips[0]
+	if g, w := trip.DistanceMiles, 0.37; g != w {
+		t.Errorf("DistanceMiles: got=%v want=%v", g, w)
+	}
+	if g, w := trip.Elapsed(), 475*time.Second; g != w {
+		t.Errorf("Elapsed: got=%v want=%v", g, w)
+	}
+	if g, w := trip.StartTime(), time.Unix(1502843903, 0); !g.Equal(w) {
+		t.Errorf("StartTime: got=%v want=%v", g, w)
+	}
+	if g, w := trip.EndTime(), time.Unix(1502844378, 0); !g.Equal(w) {
+		t.Errorf("EndTime: got=%v want=%v", g, w)
+	}
 
-func TestPlaceRetrieval(t *testing.T) {
-	client, err := uber.NewClient(testToken1)
+	// Trips survive a round trip through JSON.
+	decoded := new(uber.Trip)
+	if err := json.Unmarshal(jsonSerialize(trip), decoded); err != nil {
+		t.Fatalf("decoding the trip: %v", err)
+	}
+	if decoded.Distance != trip.Distance || decoded.DistanceMiles != trip.DistanceMiles {
+		t.Errorf("round trip: got distance=%v miles=%v want=%v and %v",
+			decoded.Distance, decoded.DistanceMiles, trip.Distance, trip.DistanceMiles)
+	}
+
+	kmTrip := new(uber.Trip)
+	if err := json.Unmarshal([]byte(`{"distance": 16.09344, "distance_unit": "km", "start_time": 100, "end_time": 160}`), kmTrip); err != nil {
+		t.Fatalf("decoding the trip in km: %v", err)
+	}
+	if g, w := fmt.Sprintf("%.3f", kmTrip.DistanceMiles), "10.000"; g != w {
+		t.Errorf("DistanceMiles: got=%s want=%s", g, w)
+	}
+	if g, w := kmTrip.Elapsed(), time.Minute; g != w {
+		t.Errorf("Elapsed without a duration: got=%v want=%v", g, w)
+	}
+
+	receipt := receiptFromFile(requestID1)
+	if g, w := string(receipt.CurrencyCode), "USD"; g != w {
+		t.Errorf("CurrencyCode: got=%q want=%q", g, w)
+	}
+	elapsed, err := receipt.Elapsed()
 	if err != nil {
-		t.Fatalf("initializing client; %v", err)
+		t.Fatalf("receipt.Elapsed: %v", err)
+	}
+	if g, w := elapsed, 11*time.Minute+35*time.Second; g != w {
+		t.Errorf("receipt.Elapsed: got=%v want=%v", g, w)
+	}
+	length, err := receipt.Length()
+	if err != nil {
+		t.Fatalf("receipt.Length: %v", err)
+	}
+	if g, w := fmt.Sprintf("%.2f", length.Miles()), "1.49"; g != w {
+		t.Errorf("receipt.Length: got=%s miles want=%s", g, w)
+	}
+	if g, w := length.String(), "2.40km"; g != w {
+		t.Errorf("receipt.Length: got=%s want=%s", g, w)
 	}
 
-	testingRoundTripper := &tRoundTripper{route: getPlacesRoute}
-	client.SetHTTPRoundTripper(testingRoundTripper)
+	bad := &uber.Receipt{Duration: "11:75:00", Distance: "3", UnitOfDistance: "furlongs"}
+	if _, err := bad.Elapsed(); !errors.Is(err, uber.ErrInvalidDuration) {
+		t.Errorf("bad duration: got err=(%v) want ErrInvalidDuration", err)
+	}
+	if _, err := bad.Length(); !errors.Is(err, uber.ErrUnknownUnit) {
+		t.Errorf("bad unit: got err=(%v) want ErrUnknownUnit", err)
+	}
+}
+
+func TestPriceDetailsEstimate(t *testing.T) {
+	product := new(uber.Product)
+	if err := readFromFileAndDeserialize("./testdata/product-a1111c8c-c720-46c3-8534-2fcdd730040d.json", product); err != nil {
+		t.Fatalf("reading the product: %v", err)
+	}
+	uberX := product.PriceDetails
+	inKM := &uber.PriceDetails{Base: 1, CostPerDistanceUnit: 0.5, DistanceUnit: uber.Unit(uber.UnitKM), CurrencyCode: "EUR"}
 
 	tests := [...]struct {
-		wantErr bool
-		place   uber.PlaceName
-		want    *uber.Place
+		pd       *uber.PriceDetails
+		distance uber.Distance
+		duration time.Duration
+		surge    float64
+
+		wantNames []string
+		wantTotal string
+		wantErr   bool
 	}{
 		0: {
-			place: "home",
-			want:  placeFromFile("685-market"),
+			pd: uberX, distance: 3 * uber.Mile, duration: 10 * time.Minute,
+			wantNames: []string{"Base Fare=2.00", "Distance=3.45", "Time=2.20", "Booking fee=1.55"},
+			wantTotal: "9.20 USD",
 		},
 		1: {
-			place: "work",
-			want:  placeFromFile("wallaby-way"),
+			// Clamped to the minimum fare of 6.55 before the booking fee.
+			pd: uberX, distance: 1 * uber.Mile, duration: 2 * time.Minute,
+			wantNames: []string{"Base Fare=2.00", "Distance=1.15", "Time=0.44", "Minimum Fare=2.96", "Booking fee=1.55"},
+			wantTotal: "8.10 USD",
 		},
 		2: {
-			place:   "workz",
-			wantErr: true,
+			pd: uberX, distance: 3 * uber.Mile, duration: 10 * time.Minute, surge: 2,
+			wantNames: []string{"Base Fare=2.00", "Distance=3.45", "Time=2.20", "Surge x2=7.65", "Booking fee=1.55"},
+			wantTotal: "16.85 USD",
 		},
 		3: {
-			place:   "",
-			wantErr: true,
+			pd: inKM, distance: 5 * uber.Kilometer,
+			wantNames: []string{"Base Fare=1.00", "Distance=2.50", "Time=0.00"},
+			wantTotal: "3.50 EUR",
 		},
+		4: {pd: nil, wantErr: true},
+		5: {pd: uberX, distance: -1 * uber.Mile, wantErr: true},
+		6: {pd: uberX, surge: 0.5, wantErr: true},
+		7: {pd: &uber.PriceDetails{DistanceUnit: "furlong"}, wantErr: true},
 	}
 
 	for i, tt := range tests {
-		place, err := client.Place(tt.place)
+		breakdown, err := tt.pd.Estimate(tt.distance, tt.duration, tt.surge)
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d expecting a non-nil error", i)
+				t.Errorf("#%d: wantErr", i)
 			}
 			continue
 		}
-
 		if err != nil {
-			t.Errorf("#%d: err: %v", i, err)
+			t.Errorf("#%d: unexpected err: %v", i, err)
 			continue
 		}
-
-		gotBlob, wantBlob := jsonSerialize(place), jsonSerialize(tt.want)
-		if !bytes.Equal(gotBlob, wantBlob) {
-			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		var gotNames []string
+		for _, item := range breakdown {
+			if item.Low != item.High {
+				t.Errorf("#%d: %q: low=%v high=%v", i, item.DisplayName, item.Low, item.High)
+			}
+			gotNames = append(gotNames, fmt.Sprintf("%s=%s", item.DisplayName, item.DisplayAmount))
+		}
+		if !reflect.DeepEqual(gotNames, tt.wantNames) {
+			t.Errorf("#%d: breakdown:\ngot= %q\nwant=%q", i, gotNames, tt.wantNames)
+		}
+		total, err := tt.pd.EstimateTotal(tt.distance, tt.duration, tt.surge)
+		if err != nil {
+			t.Errorf("#%d: EstimateTotal: %v", i, err)
+			continue
+		}
+		if g, w := total.String(), tt.wantTotal; g != w {
+			t.Errorf("#%d: total: got=%q want=%q", i, g, w)
 		}
 	}
 }
 
-var testOAuth2Token1 = &oauth2.Token{
-	AccessToken:  testOAuth2AccessToken1,
-	TokenType:    "Bearer",
-	RefreshToken: "uber-test-refresh-token",
+type compareRoundTripper struct {
+	// failTimes if set fails the retrieval of time estimates.
+	failTimes bool
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	fareLookups []string
 }
 
-func TestUpfrontFare(t *testing.T) {
+var _ http.RoundTripper = (*compareRoundTripper)(nil)
+
+// compareFares are the upfront fares of the products, by ID.
+var compareFares = map[string]string{
+	"26546650-e557-4a7b-86e7-6a3942445247": `{"fare":{"value":9.5,"currency_code":"USD","fare_id":"pool"},"trip":{"duration_estimate":1200},"pickup_estimate":1}`,
+	"a1111c8c-c720-46c3-8534-2fcdd730040d": `{"fare":{"value":12.25,"currency_code":"USD","fare_id":"uberx"},"trip":{"duration_estimate":900},"pickup_estimate":1}`,
+}
+
+func (crt *compareRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/estimates/price"):
+		return responseFromFileContent("./testdata/price-estimate-2.json"), nil
+	case strings.HasSuffix(req.URL.Path, "/estimates/time"):
+		if crt.failTimes {
+			return uberErrorResp(http.StatusInternalServerError, `{"errors":[{"status":500,"code":"internal_server_error","title":"Unavailable."}]}`), nil
+		}
+		return responseFromFileContent("./testdata/time-estimate-1.json"), nil
+	case strings.HasSuffix(req.URL.Path, "/products"):
+		return responseFromFileContent("./testdata/listProducts.json"), nil
+	case !strings.HasSuffix(req.URL.Path, "/requests/estimate"):
+		return makeResp("Not Found", http.StatusNotFound), nil
+	}
+
+	esReq := new(uber.EstimateRequest)
+	if err := json.NewDecoder(req.Body).Decode(esReq); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	crt.mu.Lock()
+	crt.fareLookups = append(crt.fareLookups, esReq.ProductID)
+	crt.inFlight += 1
+	if crt.inFlight > crt.maxInFlight {
+		crt.maxInFlight = crt.inFlight
+	}
+	crt.mu.Unlock()
+
+	// Give other lookups the chance to run concurrently.
+	time.Sleep(5 * time.Millisecond)
+
+	crt.mu.Lock()
+	crt.inFlight -= 1
+	crt.mu.Unlock()
+
+	fare, ok := compareFares[esReq.ProductID]
+	if !ok {
+		return uberErrorResp(http.StatusUnprocessableEntity, `{"errors":[{"status":422,"code":"invalid_product","title":"No upfront fare."}]}`), nil
+	}
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(strings.NewReader(fare))
+	return resp, nil
+}
+
+func TestCompareProducts(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
-	testingRoundTripper := &tRoundTripper{route: upfrontFareRoute}
-	transport := uberOAuth2.TransportWithBase(testOAuth2Token1, testingRoundTripper)
-	client.SetHTTPRoundTripper(transport)
-
-	tests := [...]struct {
-		wantErr bool
-		req     *uber.EstimateRequest
-		want    *uber.UpfrontFare
-	}{
-		0: {
-			req: &uber.EstimateRequest{
-				StartPlace: uber.PlaceHome,
+	backend := new(compareRoundTripper)
+	client.SetHTTPRoundTripper(backend)
 
-				EndLatitude:  37.7752415,
-				EndLongitude: -122.518075,
-			},
-			want: upfrontFareFromFileByID("surge"),
-		},
-		1: {
-			req: &uber.EstimateRequest{
-				StartLatitude:  37.7752415,
-				StartLongitude: -122.518075,
+	if _, err := client.CompareProducts(context.Background(), nil); err == nil {
+		t.Errorf("nil request: expected an error")
+	}
 
-				EndPlace: uber.PlaceWork,
-			},
-			want: upfrontFareFromFileByID("no-surge"),
-		},
-		2: {
-			req:     nil,
-			wantErr: true,
-		},
+	quotes, err := client.CompareProducts(context.Background(), &uber.EstimateRequest{
+		StartLatitude:  37.7752315,
+		EndLatitude:    37.7752415,
+		StartLongitude: -122.418075,
+		EndLongitude:   -122.518075,
+	})
+	if err != nil {
+		t.Fatalf("CompareProducts: %v", err)
 	}
 
-	for i, tt := range tests {
-		upfrontFare, err := client.UpfrontFare(tt.req)
-		if tt.wantErr {
-			if err == nil {
-				t.Errorf("#%d expecting a non-nil error", i)
-			}
-			continue
+	names := func(quotes []*uber.ProductQuote) []string {
+		var names []string
+		for _, quote := range quotes {
+			names = append(names, quote.Name)
 		}
+		return names
+	}
+	// Products with only a time estimate come after those with a price.
+	if g, w := names(quotes), []string{"POOL", "uberX", "uberXL", "TAXI", "SELECT", "BLACK", "SUV", "ASSIST"}; !reflect.DeepEqual(g, w) {
+		t.Fatalf("quotes:\ngot= %q\nwant=%q", g, w)
+	}
 
-		if err != nil {
-			t.Errorf("#%d: err: %v", i, err)
-			continue
+	// TAXI has no upfront fares hence is not looked up.
+	if g, w := len(backend.fareLookups), len(quotes)-1; g != w {
+		t.Errorf("upfront fare lookups: got=%d want=%d", g, w)
+	}
+	for _, productID := range backend.fareLookups {
+		if productID == quotes[3].ProductID {
+			t.Errorf("looked up the upfront fare of TAXI")
 		}
+	}
+	if g := backend.maxInFlight; g > 5 {
+		t.Errorf("got %d concurrent upfront fare lookups, want at most 5", g)
+	}
 
-		if tt.want == nil {
-			t.Errorf("#%d: inconsistency want is nil", i)
-			continue
-		}
+	pool, uberXL, taxi := quotes[0], quotes[2], quotes[3]
+	if fare, ok := pool.Fare(); !ok || fare.String() != "9.50 USD" {
+		t.Errorf("POOL fare: got=%v ok=%v want 9.50 USD", fare, ok)
+	}
+	if eta, _ := pool.PickupETA(); eta != time.Minute {
+		t.Errorf("POOL pickup: got=%v want=%v", eta, time.Minute)
+	}
+	if uberXL.UpfrontFareErr == nil || uberXL.UpfrontFare != nil {
+		t.Errorf("uberXL: expected an upfront fare error")
+	}
+	if fare, ok := uberXL.Fare(); !ok || fare.String() != "18.00 USD" {
+		t.Errorf("uberXL fare: got=%v ok=%v want the low estimate 18.00 USD", fare, ok)
+	}
+	if g, w := uberXL.SurgeMultiplier(), 1.5; g != w {
+		t.Errorf("uberXL surge: got=%v want=%v", g, w)
+	}
+	if g, w := uberXL.Capacity, 6; g != w {
+		t.Errorf("uberXL capacity: got=%d want=%d", g, w)
+	}
+	if _, ok := taxi.Fare(); ok || taxi.UpfrontFareErr != nil {
+		t.Errorf("TAXI: expected neither a fare nor an error")
+	}
 
-		gotBlob, wantBlob := jsonSerialize(upfrontFare), jsonSerialize(tt.want)
-		if !bytes.Equal(gotBlob, wantBlob) {
-			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+	tests := [...]struct {
+		sort func([]*uber.ProductQuote)
+		want []string
+	}{
+		0: {uber.SortByFare, []string{"POOL", "uberX", "uberXL", "TAXI", "SELECT", "BLACK", "SUV", "ASSIST"}},
+		1: {uber.SortByPickup, []string{"POOL", "uberX", "uberXL", "SELECT", "BLACK", "SUV", "ASSIST", "TAXI"}},
+		2: {uber.SortByArrival, []string{"uberX", "uberXL", "POOL", "TAXI", "SELECT", "BLACK", "SUV", "ASSIST"}},
+	}
+	for i, tt := range tests {
+		sorted := append([]*uber.ProductQuote(nil), quotes...)
+		tt.sort(sorted)
+		if g := names(sorted); !reflect.DeepEqual(g, tt.want) {
+			t.Errorf("#%d:\ngot= %q\nwant=%q", i, g, tt.want)
 		}
 	}
 }
 
-func TestPlaceUpdate(t *testing.T) {
+func TestCompareProductsPartialResults(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
-
-	testingRoundTripper := &tRoundTripper{route: updatePlacesRoute}
-	client.SetHTTPRoundTripper(testingRoundTripper)
-
 	tests := [...]struct {
-		wantErr bool
-		params  *uber.PlaceParams
-		want    *uber.Place
+		failTimes   bool
+		maxProducts int
+		want        []string
+		wantPartial bool
 	}{
 		0: {
-			params: &uber.PlaceParams{Place: uber.PlaceHome, Address: "P Sherman 42 Wallaby Way Sydney"},
-			want:   placeFromFile("wallaby-way"),
+			maxProducts: 3,
+			want:        []string{"POOL", "uberX", "uberXL"},
 		},
 		1: {
-			params: &uber.PlaceParams{Place: uber.PlaceWork, Address: "685 Market St, San Francisco, CA 94103, USA"},
-			want:   placeFromFile("685-market"),
-		},
-		2: {
-			params:  &uber.PlaceParams{},
-			wantErr: true,
-		},
-
-		3: {
-			// No place was specified.
-			params:  &uber.PlaceParams{Address: "685 Market St, San Francisco, CA 94103, USA"},
-			wantErr: true,
-		},
-
-		4: {
-			// No address was specified.
-			params:  &uber.PlaceParams{Place: uber.PlaceHome},
-			wantErr: true,
-		},
-
-		5: {
-			// No address was specified.
-			params:  &uber.PlaceParams{Place: uber.PlaceWork},
-			wantErr: true,
+			// The quotes of the price estimates are kept.
+			failTimes: true, wantPartial: true,
+			want: []string{"POOL", "uberX", "uberXL", "TAXI"},
 		},
 	}
 
 	for i, tt := range tests {
-		place, err := client.UpdatePlace(tt.params)
-		if tt.wantErr {
-			if err == nil {
-				t.Errorf("#%d expecting a non-nil error", i)
-			}
+		backend := &compareRoundTripper{failTimes: tt.failTimes}
+		client.SetHTTPRoundTripper(backend)
+		quotes, err := client.CompareProducts(context.Background(), &uber.EstimateRequest{
+			StartLatitude:  37.7752315,
+			EndLatitude:    37.7752415,
+			StartLongitude: -122.418075,
+			EndLongitude:   -122.518075,
+			MaxProducts:    tt.maxProducts,
+		})
+		pre := new(uber.PartialResultError)
+		if g, w := errors.As(err, &pre), tt.wantPartial; g != w {
+			t.Errorf("#%d: got partial error=%v want=%v; err=%v", i, g, w, err)
 			continue
 		}
-
-		if err != nil {
-			t.Errorf("#%d: err: %v", i, err)
+		if !tt.wantPartial && err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
 			continue
 		}
-
-		gotBlob, wantBlob := jsonSerialize(place), jsonSerialize(tt.want)
-		if !bytes.Equal(gotBlob, wantBlob) {
-			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		var names []string
+		for _, quote := range quotes {
+			names = append(names, quote.Name)
+			if tt.failTimes && quote.Time != nil {
+				t.Errorf("#%d: %s: unexpected time estimate", i, quote.Name)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("#%d:\ngot= %q\nwant=%q", i, names, tt.want)
+		}
+		// Only the quoted products have their upfront fares looked up.
+		if g, w := len(backend.fareLookups), len(quotes); g > w {
+			t.Errorf("#%d: upfront fare lookups: got=%d want at most %d", i, g, w)
 		}
 	}
 }
 
-func mapFromFile(tripID string) *uber.Map {
-	diskPath := mapPathFromRequestID(tripID)
-	save := new(uber.Map)
-	if err := readFromFileAndDeserialize(diskPath, save); err != nil {
-		return nil
+func TestSortByFareGroupsCurrencies(t *testing.T) {
+	quoteOf := func(name string, low float64, currencyCode string) *uber.ProductQuote {
+		return &uber.ProductQuote{Name: name, Price: &uber.PriceEstimate{
+			LowEstimate:  otils.NullableFloat64(low),
+			CurrencyCode: otils.NullableString(currencyCode),
+		}}
+	}
+	quotes := []*uber.ProductQuote{
+		quoteOf("uberX-USD", 12, "USD"),
+		{Name: "TAXI"},
+		quoteOf("uberX-CAD", 15, "CAD"),
+		quoteOf("POOL-USD", 9, "USD"),
+		quoteOf("POOL-CAD", 11, "CAD"),
+	}
+	uber.SortByFare(quotes)
+
+	var names []string
+	for _, quote := range quotes {
+		names = append(names, quote.Name)
+	}
+	want := []string{"POOL-CAD", "uberX-CAD", "POOL-USD", "uberX-USD", "TAXI"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got= %q\nwant=%q", names, want)
 	}
-	return save
 }
 
-func TestRequestMap(t *testing.T) {
-	client, err := uber.NewClient(testToken1)
+const ridePolicyYAML = `
+# Rides booked on behalf of employees.
+max_fares:
+  USD: 30
+  EUR: "25.50"
+max_surge_multiplier: 1.5
+allowed_product_groups: [uberx, uberxl]
+require_expense_code: true
+expense_codes:
+  - SALES
+  - ENG
+allowed_hours:
+  - start: "07:00"
+    end: "22:00"
+time_zone: UTC
+pickup_areas:
+  - name: SoMa
+    latitude: 37.7752315
+    longitude: -122.418075
+    radius_meters: 5000
+`
+
+const ridePolicyJSON = `{
+  "max_fares": {"USD": 30, "EUR": "25.50"},
+  "max_surge_multiplier": 1.5,
+  "allowed_product_groups": ["uberx", "uberxl"],
+  "require_expense_code": true,
+  "expense_codes": ["SALES", "ENG"],
+  "allowed_hours": [{"start": "07:00", "end": "22:00"}],
+  "time_zone": "UTC",
+  "pickup_areas": [{"name": "SoMa", "latitude": 37.7752315, "longitude": -122.418075, "radius_meters": 5000}]
+}`
+
+func TestRidePolicy(t *testing.T) {
+	fromYAML, err := uber.ParseRidePolicy([]byte(ridePolicyYAML))
 	if err != nil {
-		t.Fatalf("initializing client; %v", err)
+		t.Fatalf("parsing YAML: %v", err)
+	}
+	fromJSON, err := uber.ParseRidePolicy([]byte(ridePolicyJSON))
+	if err != nil {
+		t.Fatalf("parsing JSON: %v", err)
+	}
+	if g, w := jsonSerialize(fromYAML), jsonSerialize(fromJSON); !bytes.Equal(g, w) {
+		t.Fatalf("YAML and JSON policies differ:\nYAML: %s\nJSON: %s", g, w)
 	}
 
-	testingRoundTripper := &tRoundTripper{route: getMapRoute}
-	client.SetHTTPRoundTripper(testingRoundTripper)
+	badPolicies := []string{
+		`{"max_fare": {"USD": 30}}`,
+		`{"allowed_hours": [{"start": "7am", "end": "22:00"}]}`,
+		`{"pickup_areas": [{"latitude": 37.7, "longitude": -122.4}]}`,
+		`{"max_surge_multiplier": -1}`,
+	}
+	for i, blob := range badPolicies {
+		if _, err := uber.ParseRidePolicy([]byte(blob)); err == nil {
+			t.Errorf("bad policy #%d: expected an error", i)
+		}
+	}
+
+	noon := time.Date(2017, time.August, 15, 12, 0, 0, 0, time.UTC)
+	input := func(modify func(*uber.PolicyInput)) *uber.PolicyInput {
+		in := &uber.PolicyInput{
+			Ride: &uber.RideRequest{
+				StartLatitude:  37.7752315,
+				StartLongitude: -122.418075,
+				EndLatitude:    37.7752415,
+				EndLongitude:   -122.518075,
+				ExpenseCode:    "ENG",
+			},
+			Fare:            &uber.Fare{Value: 12, CurrencyCode: "USD"},
+			SurgeMultiplier: 1,
+			ProductGroup:    uber.ProductUberX,
+			At:              noon,
+		}
+		if modify != nil {
+			modify(in)
+		}
+		return in
+	}
 
 	tests := [...]struct {
-		wantErr   bool
-		requestID string
-		want      *uber.Map
+		in        *uber.PolicyInput
+		wantRules []uber.PolicyRule
 	}{
-		0: {
-			requestID: requestID1,
-			want:      mapFromFile(requestID1),
-		},
+		0: {in: input(nil)},
 		1: {
-			// Try with a random requestID.
-			requestID: fmt.Sprintf("%v", time.Now().Unix()),
-			wantErr:   true,
+			in: input(func(in *uber.PolicyInput) {
+				in.Fare.Value = 45
+				in.SurgeMultiplier = 2
+				in.ProductGroup = uber.ProductUberBlack
+				in.Ride.ExpenseCode = ""
+				in.Ride.StartLatitude, in.Ride.StartLongitude = 40.7128, -74.0060
+				in.At = noon.Add(11*time.Hour + 30*time.Minute)
+			}),
+			wantRules: []uber.PolicyRule{
+				uber.RuleMaxFare, uber.RuleMaxSurge, uber.RuleProductGroup,
+				uber.RuleExpenseCode, uber.RuleAllowedHours, uber.RulePickupArea,
+			},
+		},
+		2: {
+			in:        input(func(in *uber.PolicyInput) { in.Fare.CurrencyCode = "CAD" }),
+			wantRules: []uber.PolicyRule{uber.RuleMaxFare},
+		},
+		3: {
+			in:        input(func(in *uber.PolicyInput) { in.Fare = nil }),
+			wantRules: []uber.PolicyRule{uber.RuleMaxFare},
+		},
+		4: {
+			in:        input(func(in *uber.PolicyInput) { in.Ride.ExpenseCode = "HR" }),
+			wantRules: []uber.PolicyRule{uber.RuleExpenseCode},
+		},
+		5: {
+			in:        input(func(in *uber.PolicyInput) { in.Ride.StartPlace = uber.PlaceHome }),
+			wantRules: []uber.PolicyRule{uber.RulePickupArea},
+		},
+		6: {
+			in:        input(func(in *uber.PolicyInput) { in.ProductGroup = "" }),
+			wantRules: []uber.PolicyRule{uber.RuleProductGroup},
+		},
+		7: {
+			// Fares in EUR have their own maximum.
+			in: input(func(in *uber.PolicyInput) {
+				in.Fare = &uber.Fare{Value: 25.5, CurrencyCode: "eur"}
+			}),
 		},
 	}
 
 	for i, tt := range tests {
-		mapInfo, err := client.RequestMap(tt.requestID)
-		if tt.wantErr {
-			if err == nil {
-				t.Errorf("#%d expecting a non-nil error", i)
+		err := fromYAML.Evaluate(tt.in)
+		if len(tt.wantRules) == 0 {
+			if err != nil {
+				t.Errorf("#%d: unexpected err: %v", i, err)
 			}
 			continue
 		}
-
-		if err != nil {
-			t.Errorf("#%d: err: %v", i, err)
+		pe := new(uber.PolicyError)
+		if !errors.As(err, &pe) || !errors.Is(err, uber.ErrPolicyViolation) {
+			t.Errorf("#%d: got err=(%v) want a *PolicyError", i, err)
 			continue
 		}
+		var gotRules []uber.PolicyRule
+		for _, v := range pe.Violations {
+			gotRules = append(gotRules, v.Rule)
+		}
+		if !reflect.DeepEqual(gotRules, tt.wantRules) {
+			t.Errorf("#%d: rules:\ngot= %q\nwant=%q", i, gotRules, tt.wantRules)
+		}
+	}
+}
 
-		gotBlob, wantBlob := jsonSerialize(mapInfo), jsonSerialize(tt.want)
-		if !bytes.Equal(gotBlob, wantBlob) {
-			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+type policyRoundTripper struct {
+	mu      sync.Mutex
+	fareIDs []string
+}
+
+var _ http.RoundTripper = (*policyRoundTripper)(nil)
+
+func (prt *policyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/requests/estimate"):
+		resp := makeResp("200 OK", http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(`{"fare":{"value":5.73,"fare_id":"fare-1","currency_code":"USD"},"trip":{"product_id":"a1111c8c-c720-46c3-8534-2fcdd730040d"}}`))
+		return resp, nil
+	case strings.Contains(req.URL.Path, "/products/"):
+		return new(tRoundTripper).productByIDRoundTrip(req)
+	case req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/requests"):
+		rr := new(uber.RideRequest)
+		if err := json.NewDecoder(req.Body).Decode(rr); err != nil {
+			return makeResp(err.Error(), http.StatusBadRequest), nil
 		}
+		prt.mu.Lock()
+		prt.fareIDs = append(prt.fareIDs, rr.FareID)
+		prt.mu.Unlock()
+		return responseFromFileContent(rideFromPath(ride1)), nil
+	default:
+		return makeResp("Not Found", http.StatusNotFound), nil
 	}
 }
 
-func TestRequestReceipt(t *testing.T) {
+func TestRequestRideWithPolicy(t *testing.T) {
 	client, err := uber.NewClient(testToken1)
 	if err != nil {
 		t.Fatalf("initializing client; %v", err)
 	}
-
-	testingRoundTripper := &tRoundTripper{route: requestReceiptRoute}
-	client.SetHTTPRoundTripper(testingRoundTripper)
+	backend := new(policyRoundTripper)
+	client.SetHTTPRoundTripper(backend)
 
 	tests := [...]struct {
+		policy    *uber.RidePolicy
+		productID string
+		fareID    string
 		wantErr   bool
-		requestID string
-		want      *uber.Receipt
+		wantErrIs error
+		wantRules []uber.PolicyRule
+
+		// wantFareID is the fare that the ride is booked with.
+		wantFareID string
 	}{
 		0: {
-			requestID: requestID1,
-			want:      receiptFromFile(requestID1),
+			policy: &uber.RidePolicy{
+				MaxFares:             map[uber.CurrencyCode]uber.Decimal{"USD": uber.DecimalFromInt(5)},
+				AllowedProductGroups: []uber.ProductGroup{uber.ProductUberXL},
+				RequireExpenseCode:   true,
+			},
+			wantRules: []uber.PolicyRule{uber.RuleMaxFare, uber.RuleProductGroup, uber.RuleExpenseCode},
 		},
 		1: {
-			// Try with a random requestID.
-			requestID: fmt.Sprintf("%v", time.Now().Unix()),
+			policy: &uber.RidePolicy{
+				MaxFares:             map[uber.CurrencyCode]uber.Decimal{"USD": uber.DecimalFromInt(10)},
+				AllowedProductGroups: []uber.ProductGroup{uber.ProductUberX},
+			},
+			wantFareID: "fare-1",
+		},
+		2: {
+			// The price of the fare supplied is unknown.
+			policy: &uber.RidePolicy{
+				MaxFares: map[uber.CurrencyCode]uber.Decimal{"USD": uber.DecimalFromInt(10)},
+			},
+			fareID:    "unchecked-fare",
+			wantErr:   true,
+			wantErrIs: uber.ErrUnknownFare,
+		},
+		3: {
+			policy:    &uber.RidePolicy{MaxSurgeMultiplier: 2},
+			fareID:    "unchecked-fare",
 			wantErr:   true,
+			wantErrIs: uber.ErrUnknownFare,
+		},
+		4: {
+			policy: &uber.RidePolicy{
+				AllowedProductGroups: []uber.ProductGroup{uber.ProductUberX},
+			},
+			productID:  "a1111c8c-c720-46c3-8534-2fcdd730040d",
+			fareID:     "caller-fare",
+			wantFareID: "caller-fare",
+		},
+		5: {
+			// No fare is looked up for policies that do not need it.
+			policy: &uber.RidePolicy{
+				AllowedProductGroups: []uber.ProductGroup{uber.ProductUberX},
+			},
+			wantErr: true,
 		},
 	}
 
 	for i, tt := range tests {
-		receipt, err := client.RequestReceipt(tt.requestID)
+		client.SetRidePolicy(tt.policy)
+		backend.fareIDs = nil
+
+		ride, err := client.RequestRide(&uber.RideRequest{
+			StartLatitude:  37.7752315,
+			StartLongitude: -122.418075,
+			EndLatitude:    37.7752415,
+			EndLongitude:   -122.518075,
+			ProductID:      tt.productID,
+			FareID:         tt.fareID,
+		})
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d expecting a non-nil error", i)
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("#%d: got err=(%v) want=(%v)", i, err, tt.wantErrIs)
+			}
+			if len(backend.fareIDs) != 0 {
+				t.Errorf("#%d: unexpectedly requested the ride", i)
 			}
 			continue
 		}
-
-		if err != nil {
-			t.Errorf("#%d: err: %v", i, err)
+		if len(tt.wantRules) == 0 {
+			if err != nil {
+				t.Errorf("#%d: unexpected err: %v", i, err)
+			} else if ride == nil || !reflect.DeepEqual(backend.fareIDs, []string{tt.wantFareID}) {
+				t.Errorf("#%d: got ride=%v with fares %q, want a ride with %q", i, ride, backend.fareIDs, tt.wantFareID)
+			}
 			continue
 		}
 
-		gotBlob, wantBlob := jsonSerialize(receipt), jsonSerialize(tt.want)
-		if !bytes.Equal(gotBlob, wantBlob) {
-			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		pe := new(uber.PolicyError)
+		if !errors.As(err, &pe) {
+			t.Errorf("#%d: got err=(%v) want a *PolicyError", i, err)
+			continue
+		}
+		var gotRules []uber.PolicyRule
+		for _, v := range pe.Violations {
+			gotRules = append(gotRules, v.Rule)
+		}
+		if !reflect.DeepEqual(gotRules, tt.wantRules) {
+			t.Errorf("#%d: rules:\ngot= %q\nwant=%q", i, gotRules, tt.wantRules)
+		}
+		if len(backend.fareIDs) != 0 {
+			t.Errorf("#%d: requested the ride despite the violations", i)
 		}
 	}
 }
@@ -1406,12 +4224,22 @@ func (trt *tRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		return trt.deliveryRoundTrip(req)
 	case cancelDeliveryRoute:
 		return trt.cancelDeliveryRoundTrip(req)
+	case deliveryByIDRoute:
+		return trt.deliveryByIDRoundTrip(req)
+	case deliveryReceiptRoute:
+		return trt.deliveryReceiptRoundTrip(req)
+	case rateDeliveryRoute:
+		return trt.rateDeliveryRoundTrip(req)
 	case listDeliveriesRoute:
 		return trt.listDeliveriesRoundTrip(req)
 	case listDriverPaymentsRoute:
 		return trt.listDriverPaymentsRoundTrip(req)
 	case listDriverTripsRoute:
 		return trt.listDriverTripsRoundTrip(req)
+	case cancelRideRoute:
+		return trt.cancelRideRoundTrip(req)
+	case updateTripDestinationRoute:
+		return trt.updateTripDestinationRoundTrip(req)
 	default:
 		return makeResp("Not Found", http.StatusNotFound), nil
 	}
@@ -1475,6 +4303,10 @@ func (trt *tRoundTripper) requestRideRoundTrip(req *http.Request) (*http.Respons
 	if reflect.DeepEqual(blankRideRequest, rreq) {
 		return makeResp("expecting a valid ride request", http.StatusBadRequest), nil
 	}
+	if rreq.ProductID == surgingProductID && rreq.SurgeConfirmationID != surgeConfirmationID1 {
+		body := productErrorBodies["surging"]
+		return uberErrorResp(body.status, body.body), nil
+	}
 
 	resp := responseFromFileContent(rideFromPath(ride1))
 	return resp, nil
@@ -1716,6 +4548,74 @@ func (trt *tRoundTripper) cancelDeliveryRoundTrip(req *http.Request) (*http.Resp
 	return makeResp("204 No content", http.StatusNoContent), nil
 }
 
+func (trt *tRoundTripper) deliveryByIDRoundTrip(req *http.Request) (*http.Response, error) {
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "GET"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	splits := strings.Split(req.URL.Path, "/")
+	if len(splits) != 4 || splits[2] != "deliveries" {
+		resp := makeResp("expecting a path of form: /v1.2/deliveries/<deliveryRequestID>", http.StatusBadRequest)
+		return resp, nil
+	}
+	if !knownDeliveryID(splits[3]) {
+		return makeResp("unknown deliveryID", http.StatusNotFound), nil
+	}
+	return responseFromFileContent(deliveryResponsePath(deliveryResponseID1)), nil
+}
+
+func (trt *tRoundTripper) deliveryReceiptRoundTrip(req *http.Request) (*http.Response, error) {
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "GET"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	splits := strings.Split(req.URL.Path, "/")
+	if len(splits) != 5 || (splits[2] != "deliveries" || splits[4] != "receipt") {
+		resp := makeResp("expecting a path of form: /v1.2/deliveries/<deliveryRequestID>/receipt", http.StatusBadRequest)
+		return resp, nil
+	}
+	if !knownDeliveryID(splits[3]) {
+		return makeResp("unknown deliveryID", http.StatusNotFound), nil
+	}
+	return responseFromFileContent(deliveryReceiptPath(deliveryResponseID1)), nil
+}
+
+func (trt *tRoundTripper) rateDeliveryRoundTrip(req *http.Request) (*http.Response, error) {
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "POST"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	splits := strings.Split(req.URL.Path, "/")
+	if len(splits) != 5 || (splits[2] != "deliveries" || splits[4] != "rating") {
+		resp := makeResp("expecting a path of form: /v1.2/deliveries/<deliveryRequestID>/rating", http.StatusBadRequest)
+		return resp, nil
+	}
+	if !knownDeliveryID(splits[3]) {
+		return makeResp("unknown deliveryID", http.StatusNotFound), nil
+	}
+	defer req.Body.Close()
+	slurp, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	got, want := new(uber.DeliveryRating), new(uber.DeliveryRating)
+	if err := json.Unmarshal(slurp, got); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	if err := readFromFileAndDeserialize(deliveryRatingPath(deliveryResponseID1), want); err != nil {
+		return makeResp(err.Error(), http.StatusInternalServerError), nil
+	}
+	if !reflect.DeepEqual(got, want) {
+		return makeResp("unexpected rating", http.StatusBadRequest), nil
+	}
+	return makeResp("204 No content", http.StatusNoContent), nil
+}
+
+func deliveryReceiptPath(id string) string {
+	return fmt.Sprintf("./testdata/delivery-receipt-%s.json", id)
+}
+
+func deliveryRatingPath(id string) string {
+	return fmt.Sprintf("./testdata/delivery-rating-%s.json", id)
+}
+
 func (trt *tRoundTripper) deliveryRoundTrip(req *http.Request) (*http.Response, error) {
 	if badAuthResp, _, err := prescreenAuthAndMethod(req, "POST"); badAuthResp != nil || err != nil {
 		return badAuthResp, err
@@ -1731,6 +4631,12 @@ func (trt *tRoundTripper) deliveryRoundTrip(req *http.Request) (*http.Response,
 	if err := json.Unmarshal(slurp, dreq); err != nil {
 		return makeResp(err.Error(), http.StatusBadRequest), nil
 	}
+	if strings.HasSuffix(req.URL.Path, "/deliveries/quote") {
+		if dreq.Pickup == nil || dreq.Pickup.Location == nil || dreq.Dropoff == nil || dreq.Dropoff.Location == nil {
+			return makeResp("expecting the pickup and dropoff locations", http.StatusBadRequest), nil
+		}
+		return responseFromFileContent(deliveryQuotePath), nil
+	}
 	if err := dreq.Validate(); err != nil {
 		return makeResp(err.Error(), http.StatusBadRequest), nil
 	}
@@ -1738,9 +4644,15 @@ func (trt *tRoundTripper) deliveryRoundTrip(req *http.Request) (*http.Response,
 	// Otherwise all clear as far as the
 	// validations for the client library's request.
 	diskPath := deliveryResponsePath(deliveryResponseID1)
+	delivery := deliveryResponseFromFile(diskPath)
+	if dreq.QuoteID != "" && (delivery == nil || dreq.QuoteID != delivery.QuoteID) {
+		return makeResp("unknown quote", http.StatusBadRequest), nil
+	}
 	return responseFromFileContent(diskPath), nil
 }
 
+const deliveryQuotePath = "./testdata/delivery-quote.json"
+
 func (trt *tRoundTripper) upfrontFareRoundTrip(req *http.Request) (*http.Response, error) {
 	if badAuthResp, _, err := prescreenAuthAndMethod(req, "POST"); badAuthResp != nil || err != nil {
 		return badAuthResp, err
@@ -1873,12 +4785,114 @@ func (trt *tRoundTripper) productByIDRoundTrip(req *http.Request) (*http.Respons
 	}
 
 	productID := splits[len(splits)-1]
+	if body, ok := productErrorBodies[productID]; ok {
+		return uberErrorResp(body.status, body.body), nil
+	}
 
 	diskPath := fmt.Sprintf("./testdata/product-%s.json", productID)
 	resp := responseFromFileContent(diskPath)
 	return resp, nil
 }
 
+type errorBody struct {
+	status int
+	body   string
+}
+
+// productErrorBodies maps productIDs to the
+// error responses that Uber would send back.
+var productErrorBodies = map[string]*errorBody{
+	"not-found": {
+		status: http.StatusNotFound,
+		body:   `{"errors":[{"status":404,"code":"no_product_found","title":"No product found."}]}`,
+	},
+	"surging": {
+		status: http.StatusConflict,
+		body: `{"meta":{"surge_confirmation":{"href":"https://api.uber.com/surge-confirmations/e100a670","surge_confirmation_id":"e100a670"}},
+			"errors":[{"status":409,"code":"surge","title":"Surge pricing is currently in effect for this product."}]}`,
+	},
+	"unknown-code": {
+		status: http.StatusUnprocessableEntity,
+		body:   `{"errors":[{"status":422,"code":"brand_new_code","title":"A code unknown to this client."}]}`,
+	},
+	"legacy": {
+		status: http.StatusForbidden,
+		body:   `{"message":"This user is forbidden from making a request.","code":"forbidden"}`,
+	},
+}
+
+// rideCancellationErrorBodies maps requestIDs to the
+// error responses that Uber would send back on cancellation.
+var rideCancellationErrorBodies = map[string]*errorBody{
+	"completed": {
+		status: http.StatusConflict,
+		body:   `{"errors":[{"status":409,"code":"trip_already_completed","title":"The trip was already completed."}]}`,
+	},
+	"past-grace-period": {
+		status: http.StatusConflict,
+		body:   `{"errors":[{"status":409,"code":"cancellation_fee","title":"A cancellation fee applies."}]}`,
+	},
+	"no-current-trip": {
+		status: http.StatusNotFound,
+		body:   `{"errors":[{"status":404,"code":"no_current_trip","title":"User is not currently on a trip."}]}`,
+	},
+}
+
+func (trt *tRoundTripper) cancelRideRoundTrip(req *http.Request) (*http.Response, error) {
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "DELETE"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	splits := strings.Split(req.URL.Path, "/")
+	// Now ensure that the path is /v1.2/requests/{request_id}
+	slen := len(splits)
+	if len(splits) < 4 || splits[slen-3] != "v1.2" || splits[slen-2] != "requests" {
+		msg := fmt.Sprintf("req.URL.Path: got = %q want = /v1.2/requests/{request_id}", req.URL.Path)
+		return makeResp(msg, http.StatusBadRequest), nil
+	}
+	requestID := splits[slen-1]
+	if body, ok := rideCancellationErrorBodies[requestID]; ok {
+		return uberErrorResp(body.status, body.body), nil
+	}
+	if requestID != "current" && requestID != ride1 {
+		return makeResp("unknown requestID", http.StatusNotFound), nil
+	}
+	return makeResp("204 No content", http.StatusNoContent), nil
+}
+
+func (trt *tRoundTripper) updateTripDestinationRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "GET" {
+		// Retrieving the trip after it was updated.
+		if strings.HasSuffix(req.URL.Path, "/requests/current") {
+			return trt.currentTripRoundTrip(req)
+		}
+		return trt.tripByIDRoundTrip(req)
+	}
+
+	if badAuthResp, _, err := prescreenAuthAndMethod(req, "PATCH"); badAuthResp != nil || err != nil {
+		return badAuthResp, err
+	}
+	defer req.Body.Close()
+
+	slurp, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	du := new(uber.DestinationUpdate)
+	if err := json.Unmarshal(slurp, du); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	if err := du.Validate(); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+	return makeResp("204 No content", http.StatusNoContent), nil
+}
+
+func uberErrorResp(status int, body string) *http.Response {
+	resp := makeResp(http.StatusText(status), status)
+	resp.Body = ioutil.NopCloser(strings.NewReader(body))
+	return resp
+}
+
 func (trt *tRoundTripper) listPaymentMethodRoundTrip(req *http.Request) (*http.Response, error) {
 	if badAuthResp, _, err := prescreenAuthAndMethod(req, "GET"); badAuthResp != nil || err != nil {
 		return badAuthResp, err
@@ -2045,9 +5059,14 @@ const (
 	deliveryRoute              = "delivery"
 	sandboxTesterRoute         = "sandbox-test"
 	cancelDeliveryRoute        = "cancel-delivery"
+	deliveryByIDRoute          = "delivery-by-id"
+	deliveryReceiptRoute       = "delivery-receipt"
+	rateDeliveryRoute          = "rate-delivery"
 	listDeliveriesRoute        = "list-deliveries"
 	listDriverPaymentsRoute    = "list-driver-payments"
 	listDriverTripsRoute       = "list-driver-trips"
 	currentTripRoute           = "current-trip"
 	tripByIDRoute              = "trip-by-id"
+	cancelRideRoute            = "cancel-ride"
+	updateTripDestinationRoute = "update-trip-destination"
 )
@@ -0,0 +1,176 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Distance is a distance in meters.
+type Distance float64
+
+const (
+	Meter     Distance = 1
+	Kilometer Distance = 1000
+	Mile      Distance = 1609.344
+)
+
+var (
+	ErrUnknownUnit     = errors.New("unknown unit of distance")
+	ErrInvalidDuration = errors.New("invalid duration, expecting HH:MM:SS")
+)
+
+// unitOfDistance returns the Distance of 1 unit, as named by Uber
+// either as a unit code e.g "km" or a localized label e.g "miles".
+// A blank unit is a mile, which Uber uses by default.
+func unitOfDistance(unit string) (Distance, error) {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "", "mile", "miles", "mi":
+		return Mile, nil
+	case "km", "kms", "kilometer", "kilometers", "kilometre", "kilometres":
+		return Kilometer, nil
+	case "m", "meter", "meters", "metre", "metres":
+		return Meter, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownUnit, unit)
+	}
+}
+
+// NewDistance returns the Distance of value units, where
+// unit is as reported by Uber e.g "mile", "km" or "miles".
+func NewDistance(value float64, unit string) (Distance, error) {
+	perUnit, err := unitOfDistance(unit)
+	if err != nil {
+		return 0, err
+	}
+	return Distance(value) * perUnit, nil
+}
+
+func (d Distance) Meters() float64     { return float64(d) }
+func (d Distance) Kilometers() float64 { return float64(d / Kilometer) }
+func (d Distance) Miles() float64      { return float64(d / Mile) }
+
+// String renders d in kilometers e.g "2.40km".
+func (d Distance) String() string {
+	return fmt.Sprintf("%.2fkm", d.Kilometers())
+}
+
+// parseClockDuration parses a HH:MM:SS duration.
+func parseClockDuration(clock string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(clock), ":")
+	if len(parts) != 3 {
+		return 0, ErrInvalidDuration
+	}
+	var total time.Duration
+	for i, unit := range [...]time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, ErrInvalidDuration
+		}
+		total += time.Duration(n) * unit
+	}
+	return total, nil
+}
+
+func unixTime(secs int64) time.Time {
+	if secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// Time returns the time of the status change.
+func (sc *StatusChange) Time() time.Time {
+	return unixTime(sc.TimestampUnix)
+}
+
+// StartTime returns the time at which the trip started. Trips of
+// drivers have no start time so their pickup time or first status
+// change is used instead. It is the zero time if none is set.
+func (tr *Trip) StartTime() time.Time {
+	switch {
+	case tr.StartTimeUnix > 0:
+		return unixTime(tr.StartTimeUnix)
+	case tr.Pickup != nil && tr.Pickup.TimestampUnix > 0:
+		return unixTime(tr.Pickup.TimestampUnix)
+	case len(tr.StatusChanges) > 0:
+		return tr.StatusChanges[0].Time()
+	default:
+		return time.Time{}
+	}
+}
+
+// EndTime returns the time at which the trip ended, falling back
+// to the dropoff time. It is the zero time if none is set.
+func (tr *Trip) EndTime() time.Time {
+	switch {
+	case tr.EndTimeUnix > 0:
+		return unixTime(tr.EndTimeUnix)
+	case tr.Dropoff != nil && tr.Dropoff.TimestampUnix > 0:
+		return unixTime(tr.Dropoff.TimestampUnix)
+	default:
+		return time.Time{}
+	}
+}
+
+// Elapsed returns the duration of the trip as reported by
+// Uber, otherwise the time between its start and its end.
+func (tr *Trip) Elapsed() time.Duration {
+	if tr.Duration > 0 {
+		return time.Duration(float64(tr.Duration) * float64(time.Second))
+	}
+	start, end := tr.StartTime(), tr.EndTime()
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// Length returns the distance of the trip in its unit of distance.
+func (tr *Trip) Length() (Distance, error) {
+	return NewDistance(float64(tr.Distance), tr.Unit)
+}
+
+// UnmarshalJSON decodes the "distance" of trips into both
+// Distance and, converted to miles, DistanceMiles.
+func (tr *Trip) UnmarshalJSON(b []byte) error {
+	type trip Trip
+	if err := json.Unmarshal(b, (*trip)(tr)); err != nil {
+		return err
+	}
+	if length, err := tr.Length(); err == nil {
+		tr.DistanceMiles = length.Miles()
+	}
+	return nil
+}
+
+// Elapsed parses the duration of the trip charged.
+func (r *Receipt) Elapsed() (time.Duration, error) {
+	return parseClockDuration(string(r.Duration))
+}
+
+// Length parses the distance of the trip charged.
+func (r *Receipt) Length() (Distance, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(r.Distance)), 64)
+	if err != nil {
+		return 0, err
+	}
+	return NewDistance(value, string(r.UnitOfDistance))
+}
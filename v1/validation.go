@@ -0,0 +1,242 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uber
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"strings"
+)
+
+// ErrInvalidRequest is matched by every *ValidationError.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// FieldError is the reason why a field of a request is invalid.
+type FieldError struct {
+	// Path is the JSON path of the field
+	// e.g "dropoff.contact.phone.number".
+	Path string `json:"path"`
+
+	Err error `json:"-"`
+}
+
+var _ error = (*FieldError)(nil)
+
+func (fe *FieldError) Error() string {
+	if fe.Path == "" {
+		return fe.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", fe.Path, fe.Err)
+}
+
+func (fe *FieldError) Unwrap() error { return fe.Err }
+
+// ValidationError is returned by the Validate methods of requests
+// and reports every invalid field, in the order of the fields.
+// The reasons can be matched with errors.Is.
+type ValidationError struct {
+	Fields []*FieldError `json:"fields"`
+}
+
+var _ error = (*ValidationError)(nil)
+
+func (ve *ValidationError) Error() string {
+	msgs := make([]string, 0, len(ve.Fields))
+	for _, fe := range ve.Fields {
+		msgs = append(msgs, fe.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (ve *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+func (ve *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(ve.Fields))
+	for _, fe := range ve.Fields {
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+// Field returns the reason why the field at path is
+// invalid, or nil if that field is not reported.
+func (ve *ValidationError) Field(path string) error {
+	for _, fe := range ve.Fields {
+		if fe.Path == path {
+			return fe.Err
+		}
+	}
+	return nil
+}
+
+// validator accumulates the errors of the fields of a request.
+type validator struct {
+	fields []*FieldError
+}
+
+func (v *validator) add(path string, err error) {
+	v.fields = append(v.fields, &FieldError{Path: path, Err: err})
+}
+
+// err returns a *ValidationError if any field was invalid.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// fieldPath returns the JSON path of field within the object at path.
+func fieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func indexPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+var (
+	errInvalidLatitude     = errors.New("expecting a latitude in the range [-90, 90]")
+	errInvalidLongitude    = errors.New("expecting a longitude in the range [-180, 180]")
+	errInvalidCurrencyCode = errors.New("expecting a 3 letter uppercase ISO 4217 currency code")
+	errInvalidPhoneNumber  = errors.New("expecting an E.164 phone number such as +14155550123")
+	errBlankPhoneNumber    = errors.New("expecting a non-blank phone number")
+	errInvalidEmail        = errors.New("expecting an email address such as name@example.com")
+)
+
+func (v *validator) checkCoords(path, latField, lonField string, lat, lon float64) {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		v.add(fieldPath(path, latField), errInvalidLatitude)
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		v.add(fieldPath(path, lonField), errInvalidLongitude)
+	}
+}
+
+// Validate checks that cc is a well formed ISO 4217 code such as "USD".
+func (cc CurrencyCode) Validate() error {
+	if len(cc) != 3 {
+		return errInvalidCurrencyCode
+	}
+	for _, r := range cc {
+		if r < 'A' || r > 'Z' {
+			return errInvalidCurrencyCode
+		}
+	}
+	return nil
+}
+
+// Validate checks that the coordinates of l, if set, are in range.
+func (l *Location) Validate() error {
+	if l == nil {
+		return errNilEndpointLocation
+	}
+	v := new(validator)
+	l.validate(v, "")
+	return v.err()
+}
+
+func (l *Location) validate(v *validator, path string) {
+	v.checkCoords(path, "latitude", "longitude", l.Latitude, l.Longitude)
+}
+
+// Validate checks that the number of p is in the E.164
+// format i.e a "+" followed by 8 to 15 digits.
+func (p *Phone) Validate() error {
+	if p == nil {
+		return errBlankPhoneNumber
+	}
+	v := new(validator)
+	p.validate(v, "")
+	return v.err()
+}
+
+func (p *Phone) validate(v *validator, path string) {
+	path = fieldPath(path, "number")
+	number := strings.TrimSpace(p.Number)
+	if number == "" {
+		v.add(path, errBlankPhoneNumber)
+		return
+	}
+	if !isE164(number) {
+		v.add(path, errInvalidPhoneNumber)
+	}
+}
+
+func isE164(number string) bool {
+	digits, ok := strings.CutPrefix(number, "+")
+	if !ok || len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var errBlankContactName = errors.New("expecting either of first_name, last_name or company_name")
+
+// Validate checks that c is named and that its
+// email address and phone, if set, are well formed.
+func (c *Contact) Validate() error {
+	if c == nil {
+		return errNilEndpointContact
+	}
+	v := new(validator)
+	c.validate(v, "")
+	return v.err()
+}
+
+func (c *Contact) validate(v *validator, path string) {
+	if strings.TrimSpace(c.FirstName) == "" && strings.TrimSpace(c.LastName) == "" && strings.TrimSpace(c.CompanyName) == "" {
+		v.add(fieldPath(path, "first_name"), errBlankContactName)
+	}
+	if c.Email != "" {
+		// Display names as in "Gizmo Shop <contact@uber.com>" are rejected.
+		if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+			v.add(fieldPath(path, "email"), errInvalidEmail)
+		}
+	}
+	if c.Phone != nil {
+		c.Phone.validate(v, fieldPath(path, "phone"))
+	}
+}
+
+// checkTrip checks the fields that rides and estimates share.
+func (v *validator) checkTrip(startPlace PlaceName, startLat, startLon float64, endPlace PlaceName, endLat, endLon float64, seatCount int) {
+	if blankPlaceOrCoords(startPlace, startLat, startLon) {
+		v.add("start_place_id", ErrInvalidStartPlaceOrCoords)
+	}
+	v.checkCoords("", "start_latitude", "start_longitude", startLat, startLon)
+	if blankPlaceOrCoords(endPlace, endLat, endLon) {
+		v.add("end_place_id", ErrInvalidEndPlaceOrCoords)
+	}
+	v.checkCoords("", "end_latitude", "end_longitude", endLat, endLon)
+
+	// The number of seats required for uberPool.
+	// Default and maximum value is 2.
+	if seatCount < 0 || seatCount > 2 {
+		v.add("seat_count", errInvalidSeatCount)
+	}
+}